@@ -2,12 +2,16 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 
 	"github.com/lafriks/go-tiled"
 	"github.com/lafriks/go-tiled/render"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	"golang.org/x/image/webp"
 )
 
 func main() {
@@ -25,13 +29,18 @@ func main() {
 		return
 	}
 
-	rend, err := render.NewRenderer(m)
+	rend, err := render.NewRenderer(m,
+		render.WithImageDecoder(".bmp", bmp.Decode),
+		render.WithImageDecoder(".tif", tiff.Decode),
+		render.WithImageDecoder(".tiff", tiff.Decode),
+		render.WithImageDecoder(".webp", webp.Decode),
+	)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 
-	if err = rend.RenderVisibleLayers(); err != nil {
+	if err = rend.RenderMapParallel(context.Background(), nil); err != nil {
 		fmt.Println(err)
 		return
 	}
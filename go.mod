@@ -5,11 +5,12 @@ go 1.25.0
 require (
 	github.com/disintegration/imaging v1.6.2
 	github.com/stretchr/testify v1.11.1
+	golang.org/x/image v0.41.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/image v0.41.0 // indirect
+	golang.org/x/text v0.37.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,141 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package navigation
+
+import (
+	"container/heap"
+	"image"
+	"math"
+)
+
+// openNode is a tile waiting to be expanded by the search.
+type openNode struct {
+	index    int
+	priority float64
+}
+
+type openSet []openNode
+
+func (s openSet) Len() int { return len(s) }
+
+func (s openSet) Less(i, j int) bool { return s[i].priority < s[j].priority }
+
+func (s openSet) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s *openSet) Push(x any) { *s = append(*s, x.(openNode)) }
+
+func (s *openSet) Pop() any {
+	old := *s
+	n := old[len(old)-1]
+	*s = old[:len(old)-1]
+	return n
+}
+
+// FindPath returns the cheapest path from one tile to another, in tile
+// coordinates, including both of them. Entering a tile costs its cost times
+// the length of the move, which is 1 for moves to adjacent tiles and the
+// square root of 2 for diagonal moves.
+//
+// The start tile doesn't need to be walkable. ErrNoPath is returned if the
+// goal can't be reached, and ErrOutOfBounds if either tile lies outside of the
+// map.
+func (g *Graph) FindPath(from, to image.Point) ([]image.Point, error) {
+	path, _, err := g.findPath(from, to)
+	return path, err
+}
+
+// FindPathCost is like FindPath, but also returns the total cost of the path.
+func (g *Graph) FindPathCost(from, to image.Point) ([]image.Point, float64, error) {
+	return g.findPath(from, to)
+}
+
+func (g *Graph) findPath(from, to image.Point) ([]image.Point, float64, error) {
+	if !g.inBounds(from.X, from.Y) || !g.inBounds(to.X, to.Y) {
+		return nil, 0, ErrOutOfBounds
+	}
+	if from == to {
+		return []image.Point{from}, 0, nil
+	}
+	if !g.Walkable(to.X, to.Y) {
+		return nil, 0, ErrNoPath
+	}
+
+	// Scaling the distance by the cheapest cost keeps the heuristic
+	// admissible, so that the found path is always the cheapest one.
+	minCost := g.minCost
+	if math.IsInf(minCost, 1) {
+		minCost = 0
+	}
+	heuristic := func(x, y int) float64 {
+		return g.distance(x, y, to.X, to.Y) * minCost
+	}
+
+	start, goal := from.Y*g.width+from.X, to.Y*g.width+to.X
+	costs := map[int]float64{start: 0}
+	cameFrom := make(map[int]int)
+	closed := make(map[int]bool)
+
+	open := &openSet{{index: start, priority: heuristic(from.X, from.Y)}}
+	for open.Len() > 0 {
+		current := heap.Pop(open).(openNode).index
+		if current == goal {
+			break
+		}
+		if closed[current] {
+			continue
+		}
+		closed[current] = true
+
+		x, y := current%g.width, current/g.width
+		g.forEachNeighbor(x, y, func(nx, ny int, length float64) {
+			next := ny*g.width + nx
+			if closed[next] {
+				return
+			}
+			cost := costs[current] + g.costs[next]*length
+			if c, ok := costs[next]; ok && c <= cost {
+				return
+			}
+			costs[next] = cost
+			cameFrom[next] = current
+			heap.Push(open, openNode{index: next, priority: cost + heuristic(nx, ny)})
+		})
+	}
+
+	total, ok := costs[goal]
+	if !ok {
+		return nil, 0, ErrNoPath
+	}
+
+	var path []image.Point
+	for i := goal; ; i = cameFrom[i] {
+		path = append(path, image.Pt(i%g.width, i/g.width))
+		if i == start {
+			break
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, total, nil
+}
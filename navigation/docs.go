@@ -0,0 +1,3 @@
+// Package navigation can be used to find paths between tiles of parsed maps.
+// Supports orthogonal, isometric, staggered and hexagonal maps.
+package navigation
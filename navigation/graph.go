@@ -0,0 +1,443 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package navigation
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"github.com/lafriks/go-tiled"
+)
+
+var (
+	// ErrUnsupportedOrientation represents an error in the unsupported map orientation.
+	// It's the same error as tiled.ErrUnsupportedOrientation.
+	ErrUnsupportedOrientation = tiled.ErrUnsupportedOrientation
+	// ErrOutOfBounds represents an error that the position is outside of the map
+	ErrOutOfBounds = errors.New("tiled/navigation: position out of bounds")
+	// ErrNoPath represents an error that the goal can't be reached from the start
+	ErrNoPath = errors.New("tiled/navigation: no path found")
+)
+
+const (
+	// DefaultSolidProperty is the name of the boolean tile property that makes
+	// a tile unwalkable.
+	DefaultSolidProperty = tiled.DefaultSolidProperty
+	// DefaultCostProperty is the name of the int or float tile property that
+	// sets the cost of entering a tile.
+	DefaultCostProperty = "cost"
+)
+
+// CostFunc returns the cost of entering the tile at (x, y), given the tiles of
+// every graph layer at that position, in layer order; tiles of layers without
+// a tile there are nil. A negative or infinite cost makes the position
+// unwalkable.
+type CostFunc func(x, y int, tiles []*tiled.LayerTile) float64
+
+// Option is used to customize the graph
+type Option func(*options)
+
+type options struct {
+	layers        []*tiled.Layer
+	cost          CostFunc
+	diagonals     bool
+	solidProperty string
+	costProperty  string
+}
+
+// WithLayers sets the layers the graph is built from. Defaults to all of the
+// map's top level tile layers.
+func WithLayers(layers ...*tiled.Layer) Option {
+	return func(o *options) {
+		o.layers = layers
+	}
+}
+
+// WithCostFunc sets the function that determines the walkability and cost of
+// each position, replacing the tile properties.
+func WithCostFunc(fn CostFunc) Option {
+	return func(o *options) {
+		o.cost = fn
+	}
+}
+
+// WithProperties sets the names of the tile properties that make tiles
+// unwalkable and set their cost. The cost of a position is the highest cost of
+// its tiles, or 1 if none of them has the cost property, and the position is
+// unwalkable if any of its tiles is solid. An empty name disables the
+// property.
+func WithProperties(solid, cost string) Option {
+	return func(o *options) {
+		o.solidProperty = solid
+		o.costProperty = cost
+	}
+}
+
+// WithDiagonals allows diagonal moves between tiles of orthogonal, isometric
+// and staggered maps. A diagonal move isn't allowed to cut corners, so both
+// tiles it passes between have to be walkable. Hexagonal maps always have six
+// neighbours per tile and ignore this option.
+func WithDiagonals(diagonals bool) Option {
+	return func(o *options) {
+		o.diagonals = diagonals
+	}
+}
+
+// step is a move to a neighbouring tile, in grid coordinates.
+type step struct {
+	dx, dy int
+	length float64
+	// corners are the steps to the tiles a diagonal move passes between.
+	corners [][2]int
+}
+
+var (
+	orthogonalSteps = []step{
+		{dx: 1, length: 1}, {dx: -1, length: 1}, {dy: 1, length: 1}, {dy: -1, length: 1},
+	}
+	orthogonalDiagonalSteps = []step{
+		{dx: 1, dy: 1, length: math.Sqrt2, corners: [][2]int{{1, 0}, {0, 1}}},
+		{dx: -1, dy: 1, length: math.Sqrt2, corners: [][2]int{{-1, 0}, {0, 1}}},
+		{dx: 1, dy: -1, length: math.Sqrt2, corners: [][2]int{{1, 0}, {0, -1}}},
+		{dx: -1, dy: -1, length: math.Sqrt2, corners: [][2]int{{-1, 0}, {0, -1}}},
+	}
+	staggeredSteps = []step{
+		{dx: 1, dy: 1, length: 1}, {dx: -1, dy: 1, length: 1}, {dx: 1, dy: -1, length: 1}, {dx: -1, dy: -1, length: 1},
+	}
+	staggeredDiagonalSteps = []step{
+		{dx: 2, length: math.Sqrt2, corners: [][2]int{{1, -1}, {1, 1}}},
+		{dx: -2, length: math.Sqrt2, corners: [][2]int{{-1, -1}, {-1, 1}}},
+		{dy: 2, length: math.Sqrt2, corners: [][2]int{{-1, 1}, {1, 1}}},
+		{dy: -2, length: math.Sqrt2, corners: [][2]int{{-1, -1}, {1, -1}}},
+	}
+	hexagonalStaggerYSteps = []step{
+		{dx: 2, length: 1}, {dx: -2, length: 1},
+		{dx: 1, dy: 1, length: 1}, {dx: -1, dy: 1, length: 1}, {dx: 1, dy: -1, length: 1}, {dx: -1, dy: -1, length: 1},
+	}
+	hexagonalStaggerXSteps = []step{
+		{dy: 2, length: 1}, {dy: -2, length: 1},
+		{dx: 1, dy: 1, length: 1}, {dx: 1, dy: -1, length: 1}, {dx: -1, dy: 1, length: 1}, {dx: -1, dy: -1, length: 1},
+	}
+)
+
+// Graph is a weighted graph of the tiles of a map, used to find paths between
+// them.
+//
+// Internally positions of staggered and hexagonal maps are converted to
+// "doubled" grid coordinates, in which every other row (or column, for
+// StaggerAxis x) is shifted by one, so that neighbours are at fixed offsets.
+//
+// A Graph isn't safe for concurrent use while costs are being changed.
+type Graph struct {
+	width, height int
+	costs         []float64
+	minCost       float64
+	steps         []step
+	staggerX      bool
+	staggerOdd    bool
+	// kind is the distance heuristic: orthogonal, staggered or hexagonal.
+	kind string
+}
+
+// NewGraph builds the graph of the map's tiles.
+func NewGraph(m *tiled.Map, opts ...Option) (*Graph, error) {
+	o := &options{
+		layers:        m.Layers,
+		solidProperty: DefaultSolidProperty,
+		costProperty:  DefaultCostProperty,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.cost == nil {
+		o.cost = propertyCost(o.solidProperty, o.costProperty)
+	}
+
+	g := &Graph{
+		width:      m.Width,
+		height:     m.Height,
+		costs:      make([]float64, m.Width*m.Height),
+		staggerX:   m.StaggerAxis == tiled.AxisX,
+		staggerOdd: m.StaggerIndex != tiled.StaggerIndexEven,
+	}
+
+	switch m.Orientation {
+	case "orthogonal", "isometric":
+		g.kind = "orthogonal"
+		g.steps = orthogonalSteps
+		if o.diagonals {
+			g.steps = append(append([]step(nil), orthogonalSteps...), orthogonalDiagonalSteps...)
+		}
+	case "staggered":
+		g.kind = "staggered"
+		g.steps = staggeredSteps
+		if o.diagonals {
+			g.steps = append(append([]step(nil), staggeredSteps...), staggeredDiagonalSteps...)
+		}
+	case "hexagonal":
+		g.kind = "hexagonal"
+		g.steps = hexagonalStaggerYSteps
+		if g.staggerX {
+			g.steps = hexagonalStaggerXSteps
+		}
+	default:
+		return nil, ErrUnsupportedOrientation
+	}
+
+	tiles := make([]*tiled.LayerTile, len(o.layers))
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			i := y*g.width + x
+			for j, l := range o.layers {
+				tiles[j] = nil
+				if i < len(l.Tiles) && l.Tiles[i] != nil && !l.Tiles[i].IsNil() {
+					tiles[j] = l.Tiles[i]
+				}
+			}
+			g.costs[i] = o.cost(x, y, tiles)
+		}
+	}
+	g.updateMinCost()
+
+	return g, nil
+}
+
+// propertyCost returns the CostFunc reading the solid and cost properties of
+// tiles.
+func propertyCost(solidProperty, costProperty string) CostFunc {
+	type tileKey struct {
+		ts *tiled.Tileset
+		id uint32
+	}
+	cache := make(map[tileKey]float64)
+
+	tileCost := func(tile *tiled.LayerTile) float64 {
+		key := tileKey{tile.Tileset, tile.ID}
+		if c, ok := cache[key]; ok {
+			return c
+		}
+		c := math.NaN()
+		if tile.Tileset != nil {
+			if t, err := tile.Tileset.GetTilesetTile(tile.ID); err == nil {
+				switch {
+				case solidProperty != "" && t.Properties.GetBool(solidProperty):
+					c = math.Inf(1)
+				case costProperty != "":
+					if p := t.Properties.Get(costProperty); p != nil {
+						if p.Type == "int" {
+							c = float64(t.Properties.GetInt(costProperty))
+						} else {
+							c = t.Properties.GetFloat(costProperty)
+						}
+					}
+				}
+			}
+		}
+		cache[key] = c
+		return c
+	}
+
+	return func(_, _ int, tiles []*tiled.LayerTile) float64 {
+		cost, found := 1.0, false
+		for _, tile := range tiles {
+			if tile == nil {
+				continue
+			}
+			c := tileCost(tile)
+			if math.IsNaN(c) {
+				continue
+			}
+			if !found || c > cost || c < 0 {
+				cost, found = c, true
+			}
+			if cost < 0 || math.IsInf(cost, 1) {
+				break
+			}
+		}
+		return cost
+	}
+}
+
+func (g *Graph) updateMinCost() {
+	g.minCost = math.Inf(1)
+	for _, c := range g.costs {
+		if c >= 0 && c < g.minCost {
+			g.minCost = c
+		}
+	}
+}
+
+// Size returns the size of the graph in tiles.
+func (g *Graph) Size() (int, int) {
+	return g.width, g.height
+}
+
+// Cost returns the cost of entering the tile at (x, y).
+func (g *Graph) Cost(x, y int) float64 {
+	if !g.inBounds(x, y) {
+		return math.Inf(1)
+	}
+	return g.costs[y*g.width+x]
+}
+
+// SetCost changes the cost of entering the tile at (x, y). A negative or
+// infinite cost makes the position unwalkable.
+func (g *Graph) SetCost(x, y int, cost float64) error {
+	if !g.inBounds(x, y) {
+		return ErrOutOfBounds
+	}
+	old := g.costs[y*g.width+x]
+	g.costs[y*g.width+x] = cost
+	if cost >= 0 && cost < g.minCost {
+		g.minCost = cost
+	} else if old == g.minCost {
+		g.updateMinCost()
+	}
+	return nil
+}
+
+// Walkable reports whether the tile at (x, y) can be entered.
+func (g *Graph) Walkable(x, y int) bool {
+	c := g.Cost(x, y)
+	return c >= 0 && !math.IsInf(c, 1)
+}
+
+// Neighbors returns the walkable tiles that can be reached from (x, y) in a
+// single move.
+func (g *Graph) Neighbors(x, y int) []image.Point {
+	var neighbors []image.Point
+	g.forEachNeighbor(x, y, func(nx, ny int, _ float64) {
+		neighbors = append(neighbors, image.Pt(nx, ny))
+	})
+	return neighbors
+}
+
+func (g *Graph) inBounds(x, y int) bool {
+	return x >= 0 && y >= 0 && x < g.width && y < g.height
+}
+
+// shift returns 1 if the row or column i is staggered, 0 otherwise.
+func (g *Graph) shift(i int) int {
+	if (i&1 == 1) == g.staggerOdd {
+		return 1
+	}
+	return 0
+}
+
+// toGrid converts tile coordinates to grid coordinates.
+func (g *Graph) toGrid(x, y int) (int, int) {
+	switch {
+	case g.kind == "orthogonal":
+		return x, y
+	case g.staggerX:
+		return x, 2*y + g.shift(x)
+	default:
+		return 2*x + g.shift(y), y
+	}
+}
+
+// fromGrid converts grid coordinates to tile coordinates, reporting whether
+// they're those of a tile within the map.
+func (g *Graph) fromGrid(gx, gy int) (int, int, bool) {
+	x, y := gx, gy
+	switch {
+	case g.kind == "orthogonal":
+	case g.staggerX:
+		d := gy - g.shift(gx)
+		if d&1 != 0 {
+			return 0, 0, false
+		}
+		y = d / 2
+	default:
+		d := gx - g.shift(gy)
+		if d&1 != 0 {
+			return 0, 0, false
+		}
+		x = d / 2
+	}
+	return x, y, g.inBounds(x, y)
+}
+
+// forEachNeighbor calls fn for each walkable neighbour of (x, y), with the
+// length of the move to it.
+func (g *Graph) forEachNeighbor(x, y int, fn func(nx, ny int, length float64)) {
+	if !g.inBounds(x, y) {
+		return
+	}
+	gx, gy := g.toGrid(x, y)
+	for _, s := range g.steps {
+		nx, ny, ok := g.fromGrid(gx+s.dx, gy+s.dy)
+		if !ok || !g.Walkable(nx, ny) {
+			continue
+		}
+		blocked := false
+		for _, c := range s.corners {
+			cx, cy, ok := g.fromGrid(gx+c[0], gy+c[1])
+			if !ok || !g.Walkable(cx, cy) {
+				blocked = true
+				break
+			}
+		}
+		if !blocked {
+			fn(nx, ny, s.length)
+		}
+	}
+}
+
+// distance returns the lower bound of the number of moves, weighted by their
+// length, between two tiles.
+func (g *Graph) distance(ax, ay, bx, by int) float64 {
+	agx, agy := g.toGrid(ax, ay)
+	bgx, bgy := g.toGrid(bx, by)
+	dx, dy := agx-bgx, agy-bgy
+
+	diagonal := func(a, b int) float64 {
+		if len(g.steps) == 4 {
+			return float64(a + b)
+		}
+		return float64(max(a, b)) + (math.Sqrt2-1)*float64(min(a, b))
+	}
+
+	switch g.kind {
+	case "staggered":
+		// Rotate the grid by 45 degrees, so that the four main moves change
+		// a single coordinate.
+		return diagonal(abs(dx+dy)/2, abs(dy-dx)/2)
+	case "hexagonal":
+		dx, dy = abs(dx), abs(dy)
+		if g.staggerX {
+			dx, dy = dy, dx
+		}
+		return float64(dy + max(0, (dx-dy)/2))
+	default:
+		return diagonal(abs(dx), abs(dy))
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,301 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package navigation
+
+import (
+	"errors"
+	"image"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// gridCost returns a CostFunc reading costs from rows of characters: '#' is
+// unwalkable and digits are costs.
+func gridCost(rows ...string) CostFunc {
+	return func(x, y int, _ []*tiled.LayerTile) float64 {
+		c := rows[y][x]
+		if c == '#' {
+			return math.Inf(1)
+		}
+		if c >= '0' && c <= '9' {
+			return float64(c - '0')
+		}
+		return 1
+	}
+}
+
+func sortedPoints(points []image.Point) []image.Point {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Y != points[j].Y {
+			return points[i].Y < points[j].Y
+		}
+		return points[i].X < points[j].X
+	})
+	return points
+}
+
+func equalPoints(a, b []image.Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGraph_FindPathOrthogonal(t *testing.T) {
+	m := &tiled.Map{Orientation: "orthogonal", Width: 5, Height: 5}
+	rows := []string{
+		"..#..",
+		"..#..",
+		"..#..",
+		"..#..",
+		".....",
+	}
+	g, err := NewGraph(m, WithCostFunc(gridCost(rows...)))
+	if err != nil {
+		t.Fatalf("NewGraph() error = %v", err)
+	}
+
+	path, cost, err := g.FindPathCost(image.Pt(0, 0), image.Pt(4, 0))
+	if err != nil {
+		t.Fatalf("FindPathCost() error = %v", err)
+	}
+	if len(path) != 13 || cost != 12 {
+		t.Errorf("FindPathCost() = %v (cost %v), want 13 tiles with cost 12", path, cost)
+	}
+	if path[0] != image.Pt(0, 0) || path[len(path)-1] != image.Pt(4, 0) {
+		t.Errorf("FindPathCost() = %v, want a path from (0,0) to (4,0)", path)
+	}
+	for i := 1; i < len(path); i++ {
+		d := path[i].Sub(path[i-1])
+		if abs(d.X)+abs(d.Y) != 1 || !g.Walkable(path[i].X, path[i].Y) {
+			t.Errorf("FindPathCost() step %v -> %v isn't a valid move", path[i-1], path[i])
+		}
+	}
+
+	g, err = NewGraph(m, WithCostFunc(gridCost(rows...)), WithDiagonals(true))
+	if err != nil {
+		t.Fatalf("NewGraph() error = %v", err)
+	}
+	_, cost, err = g.FindPathCost(image.Pt(0, 0), image.Pt(4, 0))
+	if err != nil {
+		t.Fatalf("FindPathCost() error = %v", err)
+	}
+	// Diagonal moves can't cut the corner at the end of the wall, so only the
+	// first and the last moves are diagonal.
+	if want := 8 + 2*math.Sqrt2; math.Abs(cost-want) > 1e-9 {
+		t.Errorf("FindPathCost() with diagonals cost = %v, want %v", cost, want)
+	}
+}
+
+func TestGraph_Costs(t *testing.T) {
+	m := &tiled.Map{Orientation: "orthogonal", Width: 3, Height: 3}
+	g, err := NewGraph(m, WithCostFunc(gridCost(
+		".9.",
+		".9.",
+		"...",
+	)))
+	if err != nil {
+		t.Fatalf("NewGraph() error = %v", err)
+	}
+
+	path, cost, err := g.FindPathCost(image.Pt(0, 0), image.Pt(2, 0))
+	if err != nil {
+		t.Fatalf("FindPathCost() error = %v", err)
+	}
+	if cost != 6 || len(path) != 7 {
+		t.Errorf("FindPathCost() = %v (cost %v), want the 6 tile detour around the expensive tiles", path, cost)
+	}
+
+	if err := g.SetCost(1, 2, -1); err != nil {
+		t.Fatalf("SetCost() error = %v", err)
+	}
+	if _, cost, _ = g.FindPathCost(image.Pt(0, 0), image.Pt(2, 0)); cost != 10 {
+		t.Errorf("FindPathCost() after blocking the detour cost = %v, want 10", cost)
+	}
+
+	if err := g.SetCost(1, 0, math.Inf(1)); err != nil {
+		t.Fatalf("SetCost() error = %v", err)
+	}
+	if err := g.SetCost(1, 1, math.Inf(1)); err != nil {
+		t.Fatalf("SetCost() error = %v", err)
+	}
+	if _, err := g.FindPath(image.Pt(0, 0), image.Pt(2, 0)); !errors.Is(err, ErrNoPath) {
+		t.Errorf("FindPath() error = %v, want %v", err, ErrNoPath)
+	}
+	if _, err := g.FindPath(image.Pt(0, 0), image.Pt(3, 0)); !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("FindPath() error = %v, want %v", err, ErrOutOfBounds)
+	}
+}
+
+func TestGraph_Properties(t *testing.T) {
+	ts := &tiled.Tileset{
+		Tiles: []*tiled.TilesetTile{
+			{ID: 1, Properties: tiled.Properties{{Name: "solid", Type: "bool", Value: "true"}}},
+			{ID: 2, Properties: tiled.Properties{{Name: "cost", Type: "int", Value: "5"}}},
+		},
+	}
+	ground := &tiled.LayerTile{Tileset: ts, ID: 0}
+	wall := &tiled.LayerTile{Tileset: ts, ID: 1}
+	mud := &tiled.LayerTile{Tileset: ts, ID: 2}
+
+	m := &tiled.Map{
+		Orientation: "orthogonal", Width: 3, Height: 1,
+		Layers: []*tiled.Layer{
+			{Tiles: []*tiled.LayerTile{ground, ground, ground}},
+			{Tiles: []*tiled.LayerTile{tiled.NilLayerTile, mud, wall}},
+		},
+	}
+	g, err := NewGraph(m)
+	if err != nil {
+		t.Fatalf("NewGraph() error = %v", err)
+	}
+
+	for x, want := range []float64{1, 5, math.Inf(1)} {
+		if got := g.Cost(x, 0); got != want {
+			t.Errorf("Cost(%d, 0) = %v, want %v", x, got, want)
+		}
+	}
+	if g.Walkable(2, 0) {
+		t.Errorf("Walkable(2, 0) = true, want false")
+	}
+
+	g, err = NewGraph(m, WithLayers(m.Layers[0]))
+	if err != nil {
+		t.Fatalf("NewGraph() error = %v", err)
+	}
+	if !g.Walkable(2, 0) {
+		t.Errorf("Walkable(2, 0) with only the ground layer = false, want true")
+	}
+}
+
+func TestGraph_Neighbors(t *testing.T) {
+	tests := []struct {
+		name      string
+		m         *tiled.Map
+		diagonals bool
+		x, y      int
+		want      []image.Point
+	}{
+		{
+			"isometric",
+			&tiled.Map{Orientation: "isometric", Width: 4, Height: 4},
+			false, 1, 1,
+			[]image.Point{{1, 0}, {0, 1}, {2, 1}, {1, 2}},
+		},
+		{
+			"staggered y odd",
+			&tiled.Map{Orientation: "staggered", Width: 4, Height: 4, StaggerAxis: tiled.AxisY, StaggerIndex: tiled.StaggerIndexOdd},
+			false, 1, 1,
+			[]image.Point{{1, 0}, {2, 0}, {1, 2}, {2, 2}},
+		},
+		{
+			"staggered y odd with diagonals",
+			&tiled.Map{Orientation: "staggered", Width: 4, Height: 4, StaggerAxis: tiled.AxisY, StaggerIndex: tiled.StaggerIndexOdd},
+			true, 1, 1,
+			[]image.Point{{1, 0}, {2, 0}, {0, 1}, {2, 1}, {1, 2}, {2, 2}, {1, 3}},
+		},
+		{
+			"staggered x even",
+			&tiled.Map{Orientation: "staggered", Width: 4, Height: 4, StaggerAxis: tiled.AxisX, StaggerIndex: tiled.StaggerIndexEven},
+			false, 1, 1,
+			[]image.Point{{0, 0}, {2, 0}, {0, 1}, {2, 1}},
+		},
+		{
+			"hexagonal y odd, shifted row",
+			&tiled.Map{Orientation: "hexagonal", Width: 4, Height: 4, StaggerAxis: tiled.AxisY, StaggerIndex: tiled.StaggerIndexOdd},
+			false, 1, 1,
+			[]image.Point{{1, 0}, {2, 0}, {0, 1}, {2, 1}, {1, 2}, {2, 2}},
+		},
+		{
+			"hexagonal y even, shifted row",
+			&tiled.Map{Orientation: "hexagonal", Width: 4, Height: 4, StaggerAxis: tiled.AxisY, StaggerIndex: tiled.StaggerIndexEven},
+			false, 1, 2,
+			[]image.Point{{1, 1}, {2, 1}, {0, 2}, {2, 2}, {1, 3}, {2, 3}},
+		},
+		{
+			"hexagonal x odd, unshifted column",
+			&tiled.Map{Orientation: "hexagonal", Width: 4, Height: 4, StaggerAxis: tiled.AxisX, StaggerIndex: tiled.StaggerIndexOdd},
+			false, 2, 1,
+			[]image.Point{{1, 0}, {2, 0}, {3, 0}, {1, 1}, {3, 1}, {2, 2}},
+		},
+		{
+			"hexagonal corner",
+			&tiled.Map{Orientation: "hexagonal", Width: 4, Height: 4, StaggerAxis: tiled.AxisY, StaggerIndex: tiled.StaggerIndexOdd},
+			false, 0, 0,
+			[]image.Point{{1, 0}, {0, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGraph(tt.m, WithDiagonals(tt.diagonals))
+			if err != nil {
+				t.Fatalf("NewGraph() error = %v", err)
+			}
+			if got := sortedPoints(g.Neighbors(tt.x, tt.y)); !equalPoints(got, tt.want) {
+				t.Errorf("Neighbors(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraph_FindPathHexagonal(t *testing.T) {
+	m := &tiled.Map{Orientation: "hexagonal", Width: 6, Height: 6, StaggerAxis: tiled.AxisY, StaggerIndex: tiled.StaggerIndexOdd}
+	g, err := NewGraph(m)
+	if err != nil {
+		t.Fatalf("NewGraph() error = %v", err)
+	}
+
+	// Going straight down alternates between the two tiles below, so five
+	// rows down also moves two and a half tiles to the right.
+	path, err := g.FindPath(image.Pt(0, 0), image.Pt(2, 5))
+	if err != nil {
+		t.Fatalf("FindPath() error = %v", err)
+	}
+	if len(path) != 6 {
+		t.Errorf("FindPath() = %v, want 6 tiles", path)
+	}
+	for i := 1; i < len(path); i++ {
+		found := false
+		for _, n := range g.Neighbors(path[i-1].X, path[i-1].Y) {
+			found = found || n == path[i]
+		}
+		if !found {
+			t.Errorf("FindPath() step %v -> %v isn't a valid move", path[i-1], path[i])
+		}
+	}
+}
+
+func TestNewGraph_UnsupportedOrientation(t *testing.T) {
+	if _, err := NewGraph(&tiled.Map{Orientation: "spherical"}); !errors.Is(err, ErrUnsupportedOrientation) {
+		t.Errorf("NewGraph() error = %v, want %v", err, ErrUnsupportedOrientation)
+	}
+}
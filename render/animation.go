@@ -0,0 +1,386 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// ErrFrameSizeChanged represents an error in the frames of an animation being rendered at different sizes.
+var ErrFrameSizeChanged = errors.New("tiled/render: animation frame size changed")
+
+// DefaultAnimationMaxDuration is the longest animation exported when
+// AnimationOptions.MaxDuration isn't set.
+const DefaultAnimationMaxDuration = 10 * time.Second
+
+// AnimationOptions are the options for exporting animations.
+type AnimationOptions struct {
+	// MaxDuration caps the length of the exported animation, which otherwise
+	// covers the least common multiple of the lengths of the animations of
+	// the tiles shown on the visible layers, so that it loops seamlessly.
+	// Defaults to DefaultAnimationMaxDuration.
+	MaxDuration time.Duration
+	// Render renders a single frame into the cleared renderer. Defaults to
+	// RenderMap.
+	Render func(r *Renderer) error
+	// GIF holds the quantizer and drawer used for GIF frames. NumColors is
+	// ignored. When not set, frames are dithered to the Plan 9 palette with
+	// its last color replaced by transparency.
+	GIF *gif.Options
+}
+
+// animationFrame is one frame of an exported animation.
+type animationFrame struct {
+	start, duration time.Duration
+}
+
+// usedAnimations returns the animated tiles placed on the map's visible tile
+// layers, or as tile objects of its visible object groups, each once.
+func (r *Renderer) usedAnimations() []*tiled.TilesetTile {
+	seen := make(map[*tiled.TilesetTile]bool)
+	var tiles []*tiled.TilesetTile
+	add := func(t *tiled.TilesetTile) {
+		if !seen[t] {
+			seen[t] = true
+			tiles = append(tiles, t)
+		}
+	}
+
+	var walk func(nodes []tiled.LayerNode)
+	walk = func(nodes []tiled.LayerNode) {
+		for _, n := range nodes {
+			if !n.Visible() {
+				continue
+			}
+			switch {
+			case n.Layer != nil:
+				for _, c := range n.Layer.AnimatedCells() {
+					add(c.TilesetTile)
+				}
+			case n.ObjectGroup != nil:
+				for _, o := range n.ObjectGroup.Objects {
+					if o.GID == 0 || !o.Visible {
+						continue
+					}
+					tile, err := r.m.TileGIDToTile(o.GID)
+					if err != nil {
+						continue
+					}
+					if t, err := tile.Tileset.GetTilesetTile(tile.ID); err == nil && t.IsAnimated() {
+						add(t)
+					}
+				}
+			case n.Group != nil:
+				walk(n.Group.OrderedLayers())
+			}
+		}
+	}
+	walk(r.m.OrderedLayers())
+	return tiles
+}
+
+// animationTimeline returns the frames at which the animations of the tiles
+// shown on the map change, covering a full cycle of all of them or
+// maxDuration, whichever is shorter. Animated tiles the map doesn't show
+// don't lengthen it. It returns a single frame without duration if nothing
+// is animated.
+func (r *Renderer) animationTimeline(maxDuration time.Duration) []animationFrame {
+	if maxDuration <= 0 {
+		maxDuration = DefaultAnimationMaxDuration
+	}
+
+	gcd := func(a, b time.Duration) time.Duration {
+		for b != 0 {
+			a, b = b, a%b
+		}
+		return a
+	}
+
+	var length time.Duration
+	var cycles [][]time.Duration
+	for _, t := range r.usedAnimations() {
+		cycle := t.AnimationDuration()
+		if cycle <= 0 {
+			continue
+		}
+		var offsets []time.Duration
+		var offset time.Duration
+		for _, f := range t.Animation {
+			offsets = append(offsets, offset)
+			offset += time.Duration(f.Duration) * time.Millisecond
+		}
+		cycles = append(cycles, append(offsets, cycle))
+
+		if length == 0 {
+			length = cycle
+		} else if length < maxDuration {
+			length = length / gcd(length, cycle) * cycle
+		}
+	}
+	if length == 0 {
+		return []animationFrame{{}}
+	}
+	length = min(length, maxDuration)
+
+	changes := map[time.Duration]bool{0: true}
+	for _, c := range cycles {
+		cycle := c[len(c)-1]
+		for start := time.Duration(0); start < length; start += cycle {
+			for _, offset := range c[:len(c)-1] {
+				if t := start + offset; t < length {
+					changes[t] = true
+				}
+			}
+		}
+	}
+
+	times := make([]time.Duration, 0, len(changes))
+	for t := range changes {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	frames := make([]animationFrame, len(times))
+	for i, t := range times {
+		end := length
+		if i+1 < len(times) {
+			end = times[i+1]
+		}
+		frames[i] = animationFrame{start: t, duration: end - t}
+	}
+	return frames
+}
+
+// renderAnimation renders each frame of the animation, calling fn with the
+// result, which is only valid until fn returns. The render time is restored
+// afterwards, but Result is left holding the last frame.
+func (r *Renderer) renderAnimation(frames []animationFrame, opts *AnimationOptions, fn func(img *image.NRGBA, f animationFrame) error) error {
+	render := (*Renderer).RenderMap
+	if opts != nil && opts.Render != nil {
+		render = opts.Render
+	}
+
+	defer r.SetTime(r.time)
+	for _, f := range frames {
+		r.SetTime(f.start)
+		r.Clear()
+		if err := render(r); err != nil {
+			return err
+		}
+		if err := fn(r.Result, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveAsAnimatedGif renders the map's tile animations and writes them as a
+// looping animated GIF image to provided writer. Frame delays are rounded to
+// the GIF resolution of 10ms.
+func (r *Renderer) SaveAsAnimatedGif(w io.Writer, opts *AnimationOptions) error {
+	var maxDuration time.Duration
+	var gifOptions gif.Options
+	if opts != nil {
+		maxDuration = opts.MaxDuration
+		if opts.GIF != nil {
+			gifOptions = *opts.GIF
+		}
+	}
+
+	pal := gifOptions.Quantizer
+	drawer := gifOptions.Drawer
+	if drawer == nil {
+		drawer = draw.FloydSteinberg
+	}
+	defaultPalette := make(color.Palette, len(palette.Plan9))
+	copy(defaultPalette, palette.Plan9)
+	defaultPalette[len(defaultPalette)-1] = color.Transparent
+
+	anim := &gif.GIF{}
+	var end time.Duration
+	err := r.renderAnimation(r.animationTimeline(maxDuration), opts, func(img *image.NRGBA, f animationFrame) error {
+		// Round the frame boundaries rather than the durations, so that
+		// rounding errors don't add up.
+		start := int((f.start + 5*time.Millisecond) / (10 * time.Millisecond))
+		end = f.start + f.duration
+		delay := int((end+5*time.Millisecond)/(10*time.Millisecond)) - start
+		if delay == 0 && f.duration > 0 {
+			return nil
+		}
+
+		p := defaultPalette
+		if pal != nil {
+			p = pal.Quantize(make(color.Palette, 0, 256), img)
+		}
+		frame := image.NewPaletted(img.Bounds(), p)
+		drawer.Draw(frame, img.Bounds(), img, img.Bounds().Min)
+
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+		anim.Disposal = append(anim.Disposal, gif.DisposalBackground)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// SaveAsAPNG renders the map's tile animations and writes them as a looping
+// animated PNG image to provided writer. Viewers without APNG support show the
+// first frame.
+func (r *Renderer) SaveAsAPNG(w io.Writer, opts *AnimationOptions) error {
+	var maxDuration time.Duration
+	if opts != nil {
+		maxDuration = opts.MaxDuration
+	}
+	frames := r.animationTimeline(maxDuration)
+	pw := &apngWriter{w: w}
+
+	var bounds image.Rectangle
+	first := true
+	err := r.renderAnimation(frames, opts, func(img *image.NRGBA, f animationFrame) error {
+		// The header is written once the size of the rendered frames is
+		// known, which depends on the render function.
+		if first {
+			bounds = img.Bounds()
+			pw.write([]byte("\x89PNG\r\n\x1a\n"))
+
+			ihdr := make([]byte, 13)
+			binary.BigEndian.PutUint32(ihdr[0:], uint32(bounds.Dx()))
+			binary.BigEndian.PutUint32(ihdr[4:], uint32(bounds.Dy()))
+			ihdr[8] = 8 // bit depth
+			ihdr[9] = 6 // color type: RGBA
+			pw.chunk("IHDR", ihdr)
+
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+			// The number of plays is left at 0, which means looping forever.
+			pw.chunk("acTL", actl)
+		} else if img.Bounds().Size() != bounds.Size() {
+			return ErrFrameSizeChanged
+		}
+
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], pw.seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(bounds.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(bounds.Dy()))
+		binary.BigEndian.PutUint16(fctl[20:], uint16(min(f.duration.Milliseconds(), 65535)))
+		binary.BigEndian.PutUint16(fctl[22:], 1000)
+		// Offsets, dispose_op (none) and blend_op (source) are left at 0.
+		pw.seq++
+		pw.chunk("fcTL", fctl)
+
+		data, err := encodeAPNGImage(img)
+		if err != nil {
+			return err
+		}
+		if first {
+			first = false
+			pw.chunk("IDAT", data)
+			return nil
+		}
+		fdat := make([]byte, 4, 4+len(data))
+		binary.BigEndian.PutUint32(fdat, pw.seq)
+		pw.seq++
+		pw.chunk("fdAT", append(fdat, data...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pw.chunk("IEND", nil)
+	return pw.err
+}
+
+// apngWriter writes PNG chunks, keeping the first error and the APNG
+// sequence number.
+type apngWriter struct {
+	w   io.Writer
+	seq uint32
+	err error
+}
+
+func (pw *apngWriter) write(b []byte) {
+	if pw.err == nil {
+		_, pw.err = pw.w.Write(b)
+	}
+}
+
+func (pw *apngWriter) chunk(name string, data []byte) {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], name)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+
+	pw.write(header)
+	pw.write(data)
+	pw.write(binary.BigEndian.AppendUint32(nil, crc.Sum32()))
+}
+
+// encodeAPNGImage returns the compressed image data of img as 8-bit RGBA.
+// Every frame is written in the same color type, which image/png can't
+// guarantee, using the Sub filter on every row.
+func encodeAPNGImage(img *image.NRGBA) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+
+	b := img.Bounds()
+	row := make([]byte, 1+4*b.Dx())
+	row[0] = 1 // Sub filter
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		pix := img.Pix[img.PixOffset(b.Min.X, y):img.PixOffset(b.Max.X, y)]
+		for i := range pix {
+			left := byte(0)
+			if i >= 4 {
+				left = pix[i-4]
+			}
+			row[1+i] = pix[i] - left
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
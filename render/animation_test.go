@@ -0,0 +1,244 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var (
+	red   = color.NRGBA{255, 0, 0, 255}
+	green = color.NRGBA{0, 255, 0, 255}
+	blue  = color.NRGBA{0, 0, 255, 255}
+)
+
+// animatedMap is a map with two animated tiles: the first alternates between
+// red (100ms) and green (150ms), the second between blue and red (100ms each).
+const animatedMap = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="2" height="1" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="3" columns="3">
+  <image source="tiles.png" width="12" height="4"/>
+  <tile id="0">
+   <animation>
+    <frame tileid="0" duration="100"/>
+    <frame tileid="1" duration="150"/>
+   </animation>
+  </tile>
+  <tile id="2">
+   <animation>
+    <frame tileid="2" duration="100"/>
+    <frame tileid="0" duration="100"/>
+   </animation>
+  </tile>
+ </tileset>
+ <layer id="1" name="Tiles" width="2" height="1">
+  <data encoding="csv">1,3</data>
+ </layer>
+</map>`
+
+func newAnimatedRenderer(t *testing.T) *Renderer {
+	return loadTestMap(t, animatedMap, fstest.MapFS{
+		"tiles.png": {Data: solidTiles(t, 4, red, green, blue)},
+	})
+}
+
+func TestRenderer_SetTime(t *testing.T) {
+	r := newAnimatedRenderer(t)
+
+	tests := []struct {
+		time        time.Duration
+		left, right color.NRGBA
+	}{
+		{0, red, blue},
+		{99 * time.Millisecond, red, blue},
+		{100 * time.Millisecond, green, red},
+		{200 * time.Millisecond, green, blue},
+		{250 * time.Millisecond, red, blue},
+		{1100 * time.Millisecond, green, red},
+	}
+	for _, tt := range tests {
+		r.SetTime(tt.time)
+		r.Clear()
+		if err := r.RenderVisibleLayers(); err != nil {
+			t.Fatal(err)
+		}
+		if c := r.Result.NRGBAAt(1, 1); c != tt.left {
+			t.Errorf("left tile at %v = %v, want %v", tt.time, c, tt.left)
+		}
+		if c := r.Result.NRGBAAt(5, 1); c != tt.right {
+			t.Errorf("right tile at %v = %v, want %v", tt.time, c, tt.right)
+		}
+	}
+}
+
+func TestRenderer_animationTimeline(t *testing.T) {
+	r := newAnimatedRenderer(t)
+
+	ms := func(v ...int) []time.Duration {
+		d := make([]time.Duration, len(v))
+		for i := range v {
+			d[i] = time.Duration(v[i]) * time.Millisecond
+		}
+		return d
+	}
+	tests := []struct {
+		name        string
+		maxDuration time.Duration
+		starts      []time.Duration
+		length      time.Duration
+	}{
+		{
+			name:   "full cycle",
+			starts: ms(0, 100, 200, 250, 300, 350, 400, 500, 600, 700, 750, 800, 850, 900),
+			length: time.Second,
+		},
+		{
+			name:        "capped",
+			maxDuration: 300 * time.Millisecond,
+			starts:      ms(0, 100, 200, 250),
+			length:      300 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		frames := r.animationTimeline(tt.maxDuration)
+		if len(frames) != len(tt.starts) {
+			t.Errorf("%s: got %d frames, want %d", tt.name, len(frames), len(tt.starts))
+			continue
+		}
+		var total time.Duration
+		for i, f := range frames {
+			if f.start != tt.starts[i] {
+				t.Errorf("%s: frame %d starts at %v, want %v", tt.name, i, f.start, tt.starts[i])
+			}
+			total += f.duration
+		}
+		if total != tt.length {
+			t.Errorf("%s: length = %v, want %v", tt.name, total, tt.length)
+		}
+	}
+}
+
+func TestRenderer_animationTimeline_UsedTiles(t *testing.T) {
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 4, red, green, blue)}}
+	// The green tile alternates with red every 70ms, which would make the
+	// animation loop only after 7s.
+	unused := strings.Replace(animatedMap, `  <tile id="2">`, `  <tile id="1">
+   <animation>
+    <frame tileid="1" duration="70"/>
+    <frame tileid="0" duration="70"/>
+   </animation>
+  </tile>
+  <tile id="2">`, 1)
+
+	tests := []struct {
+		name   string
+		tmx    string
+		length time.Duration
+	}{
+		{"unused", unused, time.Second},
+		{"hidden tile object", strings.Replace(unused, "</map>", `<objectgroup id="2" name="objects" visible="0">
+  <object id="1" gid="2" x="0" y="4" width="4" height="4"/>
+ </objectgroup>
+</map>`, 1), time.Second},
+		{"tile object", strings.Replace(unused, "</map>", `<objectgroup id="2" name="objects">
+  <object id="1" gid="2" x="0" y="4" width="4" height="4"/>
+ </objectgroup>
+</map>`, 1), 7 * time.Second},
+	}
+	for _, tt := range tests {
+		r := loadTestMap(t, tt.tmx, files)
+		frames := r.animationTimeline(0)
+		last := frames[len(frames)-1]
+		if got := last.start + last.duration; got != tt.length {
+			t.Errorf("%s: animation length = %v, want %v", tt.name, got, tt.length)
+		}
+	}
+}
+
+func TestRenderer_SaveAsAnimatedGif(t *testing.T) {
+	r := newAnimatedRenderer(t)
+	r.SetTime(42 * time.Millisecond)
+
+	var buf bytes.Buffer
+	if err := r.SaveAsAnimatedGif(&buf, &AnimationOptions{MaxDuration: 300 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if r.Time() != 42*time.Millisecond {
+		t.Errorf("time = %v, want it restored to 42ms", r.Time())
+	}
+
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{10, 10, 5, 5}
+	if len(g.Delay) != len(want) {
+		t.Fatalf("got delays %v, want %v", g.Delay, want)
+	}
+	for i := range want {
+		if g.Delay[i] != want[i] {
+			t.Errorf("got delays %v, want %v", g.Delay, want)
+			break
+		}
+	}
+	if g.LoopCount != 0 {
+		t.Errorf("loop count = %d, want 0", g.LoopCount)
+	}
+	if c := color.NRGBAModel.Convert(g.Image[1].At(1, 1)); c != green {
+		t.Errorf("second frame left tile = %v, want %v", c, green)
+	}
+}
+
+func TestRenderer_SaveAsAPNG(t *testing.T) {
+	r := newAnimatedRenderer(t)
+
+	var buf bytes.Buffer
+	if err := r.SaveAsAPNG(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Decoders without APNG support see the first frame.
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := color.NRGBAModel.Convert(img.At(5, 1)); c != blue {
+		t.Errorf("first frame right tile = %v, want %v", c, blue)
+	}
+
+	for _, chunk := range []string{"acTL", "fcTL", "fdAT"} {
+		if !bytes.Contains(buf.Bytes(), []byte(chunk)) {
+			t.Errorf("missing %s chunk", chunk)
+		}
+	}
+	if n := bytes.Count(buf.Bytes(), []byte("fcTL")); n != 14 {
+		t.Errorf("got %d frames, want 14", n)
+	}
+}
@@ -0,0 +1,266 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+// ErrUnsupportedBlendMode represents an error in the unsupported layer compositing mode.
+var ErrUnsupportedBlendMode = errors.New("tiled/render: unsupported blend mode")
+
+// separableBlends are the blend functions of the separable blend modes, which
+// combine each color channel of the backdrop cb and the source cs, both
+// non-premultiplied and in the range 0 to 1, independently.
+var separableBlends = map[string]func(cb, cs float64) float64{
+	"multiply": func(cb, cs float64) float64 { return cb * cs },
+	"screen":   screen,
+	"overlay":  func(cb, cs float64) float64 { return hardLight(cs, cb) },
+	"darken":   math.Min,
+	"lighten":  math.Max,
+	"color-dodge": func(cb, cs float64) float64 {
+		switch {
+		case cb == 0:
+			return 0
+		case cs >= 1:
+			return 1
+		}
+		return math.Min(1, cb/(1-cs))
+	},
+	"color-burn": func(cb, cs float64) float64 {
+		switch {
+		case cb >= 1:
+			return 1
+		case cs == 0:
+			return 0
+		}
+		return 1 - math.Min(1, (1-cb)/cs)
+	},
+	"hard-light": hardLight,
+	"soft-light": func(cb, cs float64) float64 {
+		if cs <= 0.5 {
+			return cb - (1-2*cs)*cb*(1-cb)
+		}
+		d := math.Sqrt(cb)
+		if cb <= 0.25 {
+			d = ((16*cb-12)*cb + 4) * cb
+		}
+		return cb + (2*cs-1)*(d-cb)
+	},
+	"difference": func(cb, cs float64) float64 { return math.Abs(cb - cs) },
+	"exclusion":  func(cb, cs float64) float64 { return cb + cs - 2*cb*cs },
+}
+
+func screen(cb, cs float64) float64 {
+	return cb + cs - cb*cs
+}
+
+func hardLight(cb, cs float64) float64 {
+	if cs <= 0.5 {
+		return cb * 2 * cs
+	}
+	return screen(cb, 2*cs-1)
+}
+
+// nonSeparableBlends are the blend functions of the blend modes that mix
+// the hue, saturation and luminosity of the backdrop and the source.
+var nonSeparableBlends = map[string]func(cb, cs [3]float64) [3]float64{
+	"hue": func(cb, cs [3]float64) [3]float64 {
+		return setLum(setSat(cs, sat(cb)), lum(cb))
+	},
+	"saturation": func(cb, cs [3]float64) [3]float64 {
+		return setLum(setSat(cb, sat(cs)), lum(cb))
+	},
+	"color": func(cb, cs [3]float64) [3]float64 {
+		return setLum(cs, lum(cb))
+	},
+	"luminosity": func(cb, cs [3]float64) [3]float64 {
+		return setLum(cb, lum(cs))
+	},
+}
+
+func lum(c [3]float64) float64 {
+	return 0.3*c[0] + 0.59*c[1] + 0.11*c[2]
+}
+
+func setLum(c [3]float64, l float64) [3]float64 {
+	d := l - lum(c)
+	for i := range c {
+		c[i] += d
+	}
+
+	l = lum(c)
+	n := math.Min(c[0], math.Min(c[1], c[2]))
+	x := math.Max(c[0], math.Max(c[1], c[2]))
+	for i := range c {
+		if n < 0 {
+			c[i] = l + (c[i]-l)*l/(l-n)
+		}
+		if x > 1 {
+			c[i] = l + (c[i]-l)*(1-l)/(x-l)
+		}
+	}
+	return c
+}
+
+func sat(c [3]float64) float64 {
+	return math.Max(c[0], math.Max(c[1], c[2])) - math.Min(c[0], math.Min(c[1], c[2]))
+}
+
+func setSat(c [3]float64, s float64) [3]float64 {
+	minI, midI, maxI := 0, 1, 2
+	if c[minI] > c[midI] {
+		minI, midI = midI, minI
+	}
+	if c[midI] > c[maxI] {
+		midI, maxI = maxI, midI
+	}
+	if c[minI] > c[midI] {
+		minI, midI = midI, minI
+	}
+
+	var r [3]float64
+	if c[maxI] > c[minI] {
+		r[midI] = (c[midI] - c[minI]) * s / (c[maxI] - c[minI])
+		r[maxI] = s
+	}
+	return r
+}
+
+// normalizeBlendMode returns the blend mode in the hyphenated form Tiled
+// writes, so that "color dodge" and "Color-Dodge" are both "color-dodge".
+// Unset modes are normal.
+func normalizeBlendMode(mode string) string {
+	mode = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(mode)), " ", "-")
+	switch mode {
+	case "":
+		return "normal"
+	case "multiply-(legacy)":
+		return "multiply"
+	}
+	return mode
+}
+
+// supportedBlendMode reports whether mode, normalized, is a blend mode the
+// renderer implements.
+func supportedBlendMode(mode string) bool {
+	return mode == "normal" || mode == "add" || separableBlends[mode] != nil || nonSeparableBlends[mode] != nil
+}
+
+// compose composites src onto dst at the same position with the given blend
+// mode, scaling the alpha of src by opacity.
+func compose(dst, src *image.NRGBA, mode string, opacity float64) error {
+	mode = normalizeBlendMode(mode)
+	opacity = math.Max(0, math.Min(opacity, 1))
+
+	if !supportedBlendMode(mode) {
+		return ErrUnsupportedBlendMode
+	}
+	if mode == "normal" {
+		var mask image.Image
+		if opacity < 1 {
+			mask = image.NewUniform(color.Alpha{uint8(opacity * 255)})
+		}
+		draw.DrawMask(dst, src.Bounds(), src, src.Bounds().Min, mask, image.Point{}, draw.Over)
+		return nil
+	}
+
+	separable := separableBlends[mode]
+	nonSeparable := nonSeparableBlends[mode]
+
+	rect := dst.Bounds().Intersect(src.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			s := src.Pix[src.PixOffset(x, y):][:4]
+			as := float64(s[3]) / 255 * opacity
+			if as == 0 {
+				continue
+			}
+			d := dst.Pix[dst.PixOffset(x, y):][:4]
+			ab := float64(d[3]) / 255
+
+			var cs, cb [3]float64
+			for i := range 3 {
+				cs[i] = float64(s[i]) / 255
+				cb[i] = float64(d[i]) / 255
+			}
+
+			// Premultiplied result of blending, before dividing by alpha.
+			var co [3]float64
+			ao := as + ab*(1-as)
+			if mode == "add" {
+				ao = math.Min(1, as+ab)
+				for i := range co {
+					co[i] = cs[i]*as + cb[i]*ab
+				}
+			} else {
+				var blended [3]float64
+				if nonSeparable != nil {
+					blended = nonSeparable(cb, cs)
+				} else {
+					for i := range blended {
+						blended[i] = separable(cb[i], cs[i])
+					}
+				}
+				for i := range co {
+					co[i] = cs[i]*as*(1-ab) + cb[i]*ab*(1-as) + as*ab*blended[i]
+				}
+			}
+
+			for i := range co {
+				d[i] = uint8(math.Round(math.Max(0, math.Min(1, co[i]/ao)) * 255))
+			}
+			d[3] = uint8(math.Round(ao * 255))
+		}
+	}
+	return nil
+}
+
+// renderBlended calls render to draw a layer and composites the result with
+// the given blend mode and opacity. Layers with the normal blend mode and
+// full opacity are drawn directly, others are rendered into an intermediate
+// buffer first, so that they blend with what is below them as a whole.
+func (r *Renderer) renderBlended(mode string, opacity float32, render func() error) error {
+	mode = normalizeBlendMode(mode)
+	if mode == "normal" && opacity >= 1 {
+		return render()
+	}
+	if !supportedBlendMode(mode) {
+		return ErrUnsupportedBlendMode
+	}
+
+	result := r.Result
+	r.Result = image.NewNRGBA(result.Bounds())
+	err := render()
+	buffer := r.Result
+	r.Result = result
+	if err != nil {
+		return err
+	}
+	return compose(r.Result, buffer, mode, float64(opacity))
+}
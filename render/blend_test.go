@@ -0,0 +1,137 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCompose(t *testing.T) {
+	backdrop := color.NRGBA{200, 100, 50, 255}
+	source := color.NRGBA{100, 100, 100, 255}
+
+	tests := []struct {
+		mode    string
+		source  color.NRGBA
+		opacity float64
+		want    color.NRGBA
+		// empty checks the pixel where the backdrop is transparent.
+		empty bool
+	}{
+		{"normal", source, 1, source, false},
+		{"", source, 0.5, color.NRGBA{150, 100, 75, 255}, false},
+		{"multiply", source, 1, color.NRGBA{78, 39, 20, 255}, false},
+		{"multiply", source, 0.5, color.NRGBA{139, 70, 35, 255}, false},
+		{"screen", source, 1, color.NRGBA{222, 161, 130, 255}, false},
+		{"darken", source, 1, color.NRGBA{100, 100, 50, 255}, false},
+		{"lighten", source, 1, color.NRGBA{200, 100, 100, 255}, false},
+		{"difference", source, 1, color.NRGBA{100, 0, 50, 255}, false},
+		{"add", source, 1, color.NRGBA{255, 200, 150, 255}, false},
+		{"color dodge", color.NRGBA{255, 0, 0, 255}, 1, color.NRGBA{255, 100, 50, 255}, false},
+		{"luminosity", color.NRGBA{0, 0, 0, 255}, 1, color.NRGBA{0, 0, 0, 255}, false},
+		// Blending onto nothing leaves the source as it is.
+		{"multiply", color.NRGBA{10, 20, 30, 255}, 1, color.NRGBA{10, 20, 30, 255}, true},
+	}
+
+	for _, tt := range tests {
+		dst := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+		dst.SetNRGBA(0, 0, backdrop)
+		src := image.NewNRGBA(dst.Rect)
+		src.SetNRGBA(0, 0, tt.source)
+		src.SetNRGBA(1, 0, tt.source)
+
+		if err := compose(dst, src, tt.mode, tt.opacity); err != nil {
+			t.Errorf("compose(%q) error = %v", tt.mode, err)
+			continue
+		}
+		got := dst.NRGBAAt(0, 0)
+		if tt.empty {
+			got = dst.NRGBAAt(1, 0)
+		}
+		if got != tt.want {
+			t.Errorf("compose(%q, %v, %v) = %v, want %v", tt.mode, tt.source, tt.opacity, got, tt.want)
+		}
+	}
+
+	if err := compose(image.NewNRGBA(image.Rect(0, 0, 1, 1)), image.NewNRGBA(image.Rect(0, 0, 1, 1)), "bogus", 1); !errors.Is(err, ErrUnsupportedBlendMode) {
+		t.Errorf("compose(bogus) error = %v, want %v", err, ErrUnsupportedBlendMode)
+	}
+}
+
+func TestRenderer_RenderGroup_BlendMode(t *testing.T) {
+	grey := color.NRGBA{128, 128, 128, 255}
+	files := fstest.MapFS{
+		"bg.png":   {Data: solidTiles(t, 4, red)},
+		"grey.png": {Data: solidTiles(t, 2, grey)},
+	}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="1" height="1" tilewidth="4" tileheight="4">
+ <imagelayer id="1" name="bg"><image source="bg.png"/></imagelayer>
+ <group id="2" name="shade"%s>
+  <imagelayer id="3" name="a"><image source="grey.png"/></imagelayer>
+  <imagelayer id="4" name="b" offsetx="1"><image source="grey.png"/></imagelayer>
+ </group>
+</map>`
+
+	tests := []struct {
+		attrs string
+		want  map[image.Point]color.NRGBA
+		err   error
+	}{
+		{
+			// The group's layers are composited together before the group
+			// is multiplied, so where they overlap they don't darken twice.
+			attrs: ` mode="multiply"`,
+			want:  map[image.Point]color.NRGBA{{0, 0}: {128, 0, 0, 255}, {1, 0}: {128, 0, 0, 255}, {3, 0}: red},
+		},
+		{
+			attrs: ` opacity="0.5"`,
+			want:  map[image.Point]color.NRGBA{{1, 0}: {192, 63, 63, 255}},
+		},
+		{
+			attrs: ` mode="bogus"`,
+			err:   ErrUnsupportedBlendMode,
+		},
+	}
+
+	for _, tt := range tests {
+		r := loadTestMap(t, fmt.Sprintf(tmx, tt.attrs), files)
+		if err := r.RenderVisibleImageLayers(); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.RenderVisibleGroups(); !errors.Is(err, tt.err) {
+			t.Errorf("%s: error = %v, want %v", tt.attrs, err, tt.err)
+			continue
+		}
+		for p, want := range tt.want {
+			if c := r.Result.NRGBAAt(p.X, p.Y); c != want {
+				t.Errorf("%s: pixel at %v = %v, want %v", tt.attrs, p, c, want)
+			}
+		}
+	}
+}
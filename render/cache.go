@@ -0,0 +1,205 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"container/list"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+)
+
+// TileImageCache caches the tileset and image layer images decoded by
+// renderers, and the tile images cut from them, already flipped and rotated,
+// fitted into grid cells and tinted.
+// It is safe for concurrent use, so a single cache can be shared by renderers
+// of maps using the same tilesets, in any number of goroutines, to decode and
+// cut each tile once. Images are identified by their file path, so renderers
+// sharing a cache must read the same files.
+type TileImageCache struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	entries map[any]*cacheEntry
+	// lru holds the loaded entries, the most recently used first.
+	lru list.List
+}
+
+// cacheEntry is an image in a TileImageCache, which is being loaded until
+// ready is closed.
+type cacheEntry struct {
+	key   any
+	img   image.Image
+	err   error
+	size  int64
+	ready chan struct{}
+	elem  *list.Element
+}
+
+// sourceImageKey identifies an image file, with pixels of the trans color
+// made transparent if keyed is set.
+type sourceImageKey struct {
+	path  string
+	trans color.NRGBA
+	keyed bool
+}
+
+// tileImageKey identifies the image of a tile with the given flips, which is
+// rotated differently depending on the orientation.
+type tileImageKey struct {
+	source      sourceImageKey
+	rect        image.Rectangle
+	orientation string
+	d, h, v     bool
+	rotated120  bool
+}
+
+// fittedTileKey identifies the image of a tile fitted into a grid cell of the
+// given size with the tileset's fill mode.
+type fittedTileKey struct {
+	tile     tileImageKey
+	size     image.Point
+	fillMode string
+}
+
+// tintedTileKey identifies the image of a tile, fitted into a grid cell if
+// fitted is set, with the tint color applied.
+type tintedTileKey struct {
+	tile   fittedTileKey
+	fitted bool
+	tint   color.NRGBA
+}
+
+// tintedImageKey identifies an image layer image with the tint color applied.
+type tintedImageKey struct {
+	source sourceImageKey
+	tint   color.NRGBA
+}
+
+// NewTileImageCache returns an empty cache. If maxBytes is positive, the
+// least recently used images are evicted to keep the pixel data cached within
+// maxBytes, and images larger than that aren't cached at all.
+func NewTileImageCache(maxBytes int64) *TileImageCache {
+	return &TileImageCache{
+		maxBytes: maxBytes,
+		entries:  make(map[any]*cacheEntry),
+	}
+}
+
+// Len returns the number of images in the cache.
+func (c *TileImageCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Size returns the size in bytes of the pixel data of the images in the
+// cache, counting four bytes per pixel.
+func (c *TileImageCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Clear removes all images from the cache.
+func (c *TileImageCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[any]*cacheEntry)
+	c.lru.Init()
+	c.size = 0
+}
+
+// cached returns the image cached with key, if it's loaded. Unlike load, it
+// doesn't keep key, so looking up a cached image doesn't allocate.
+func (c *TileImageCache) cached(key any) (image.Image, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || e.elem == nil {
+		return nil, false
+	}
+	c.lru.MoveToFront(e.elem)
+	return e.img, true
+}
+
+// load returns the image cached with key, calling load to create it if it
+// isn't cached. Concurrent calls for the same key wait for the first one to
+// load it. Errors aren't cached.
+func (c *TileImageCache) load(key any, load func() (image.Image, error)) (image.Image, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		if e.elem != nil {
+			c.lru.MoveToFront(e.elem)
+		}
+		c.mu.Unlock()
+		<-e.ready
+		return e.img, e.err
+	}
+	e := &cacheEntry{key: key, ready: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	e.img, e.err = load()
+	if e.err == nil {
+		e.size = imageBytes(e.img)
+	}
+	close(e.ready)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// The cache may have been cleared while the image was loading.
+	if c.entries[key] != e {
+		return e.img, e.err
+	}
+	// Images of uncountable size, which can't be counted towards the total
+	// either, are never cached.
+	if e.err != nil || e.size == math.MaxInt64 || (c.maxBytes > 0 && e.size > c.maxBytes) {
+		delete(c.entries, key)
+		return e.img, e.err
+	}
+
+	e.elem = c.lru.PushFront(e)
+	c.size += e.size
+	for c.maxBytes > 0 && c.size > c.maxBytes {
+		oldest := c.lru.Back().Value.(*cacheEntry)
+		c.lru.Remove(oldest.elem)
+		delete(c.entries, oldest.key)
+		c.size -= oldest.size
+	}
+	return e.img, e.err
+}
+
+// imageBytes returns the size of the pixel data of img at four bytes per
+// pixel, or math.MaxInt64 for images too large to count, such as
+// image.Uniform.
+func imageBytes(img image.Image) int64 {
+	b := img.Bounds()
+	w, h := int64(b.Dx()), int64(b.Dy())
+	if w > 0 && h > math.MaxInt64/4/w {
+		return math.MaxInt64
+	}
+	return w * h * 4
+}
@@ -0,0 +1,127 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"image"
+	"image/png"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTileImageCache_Shared(t *testing.T) {
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 4, red, blue)}}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="3" height="1" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="2" columns="2">
+  <image source="tiles.png" width="8" height="4"/>
+ </tileset>
+ <layer id="1" name="tiles" width="3" height="1"><data encoding="csv">1,2,2147483649</data></layer>
+</map>`
+
+	var decodes atomic.Int32
+	decode := func(rd io.Reader) (image.Image, error) {
+		decodes.Add(1)
+		return png.Decode(rd)
+	}
+	cache := NewTileImageCache(0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		r := loadTestMap(t, tmx, files)
+		WithImageDecoder(".png", decode)(r)
+		WithTileImageCache(cache)(r)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.RenderVisibleLayers(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := decodes.Load(); n != 1 {
+		t.Errorf("tileset image decoded %d times, want 1", n)
+	}
+	// The tileset image, both tiles and the flipped copy of the first one.
+	if n := cache.Len(); n != 4 {
+		t.Errorf("cache holds %d images, want 4", n)
+	}
+	if size := cache.Size(); size != (8*4+3*4*4)*4 {
+		t.Errorf("cache size = %d, want %d", size, (8*4+3*4*4)*4)
+	}
+
+	cache.Clear()
+	if n, size := cache.Len(), cache.Size(); n != 0 || size != 0 {
+		t.Errorf("cleared cache holds %d images of %d bytes", n, size)
+	}
+}
+
+func TestTileImageCache_MaxBytes(t *testing.T) {
+	// Room for two 2x2 images.
+	cache := NewTileImageCache(32)
+	loads := map[string]int{}
+	load := func(key string, size int) {
+		t.Helper()
+		img, err := cache.load(key, func() (image.Image, error) {
+			loads[key]++
+			return image.NewNRGBA(image.Rect(0, 0, size, size)), nil
+		})
+		if err != nil || img.Bounds().Dx() != size {
+			t.Fatalf("load(%q) = %v, %v", key, img.Bounds(), err)
+		}
+	}
+
+	load("a", 2)
+	load("b", 2)
+	load("a", 2)
+	load("c", 2) // evicts b, the least recently used
+	load("a", 2)
+	load("b", 2)
+	load("big", 3) // too big to be cached
+	load("big", 3)
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.load("uniform", func() (image.Image, error) {
+			loads["uniform"]++
+			return image.NewUniform(red), nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]int{"a": 1, "b": 2, "c": 1, "big": 2, "uniform": 2}
+	for key, n := range want {
+		if loads[key] != n {
+			t.Errorf("%q loaded %d times, want %d", key, loads[key], n)
+		}
+	}
+	if n, size := cache.Len(), cache.Size(); n != 2 || size != 32 {
+		t.Errorf("cache holds %d images of %d bytes, want 2 of 32", n, size)
+	}
+}
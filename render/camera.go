@@ -0,0 +1,111 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+// ErrInvalidCamera represents an error in the camera having no size or a negative zoom.
+var ErrInvalidCamera = errors.New("tiled/render: invalid camera")
+
+// Camera is a view of the map, as the game would show it.
+type Camera struct {
+	// Center of the view, in screen pixels of the whole rendered map.
+	CenterX, CenterY float64
+	// Size of the rendered image in pixels.
+	Width, Height int
+	// Zoom scales the view, so that a zoom of 2 shows a quarter of the area
+	// a zoom of 1 does. Defaults to 1 if zero.
+	Zoom float64
+}
+
+// RenderCamera clears the result and renders what the camera sees of all
+// visible layers into it, with each layer moved by its parallax factor
+// relative to the map's parallax origin. The result is Width x Height pixels.
+func (r *Renderer) RenderCamera(c Camera) error {
+	zoom := c.Zoom
+	if zoom == 0 {
+		zoom = 1
+	}
+	if c.Width <= 0 || c.Height <= 0 || zoom < 0 {
+		return ErrInvalidCamera
+	}
+
+	// Render the visible area at the map's own scale and zoom afterwards, so
+	// that parallax offsets, which are in map pixels, don't depend on zoom.
+	w, h := float64(c.Width)/zoom, float64(c.Height)/zoom
+	view := image.Rect(
+		int(math.Floor(c.CenterX-w/2)), int(math.Floor(c.CenterY-h/2)),
+		int(math.Ceil(c.CenterX+w/2)), int(math.Ceil(c.CenterY+h/2)),
+	)
+
+	r.camera, r.cull = &c, true
+	defer func() { r.camera, r.cull = nil, false }()
+	r.Result = image.NewNRGBA(view)
+
+	if err := r.renderMapLayers(); err != nil {
+		return err
+	}
+
+	if zoom == 1 && view.Dx() == c.Width && view.Dy() == c.Height {
+		r.Result.Rect = image.Rect(0, 0, c.Width, c.Height)
+		return nil
+	}
+	r.Result = imaging.Resize(r.Result, c.Width, c.Height, imaging.NearestNeighbor)
+	return nil
+}
+
+// renderParallax calls render with the result moved by the parallax offset of
+// a layer with the given parallax factor, which is compounded with those of
+// the groups it is in. Layers are only moved when rendering through a camera.
+func (r *Renderer) renderParallax(parallaxX, parallaxY float32, render func() error) error {
+	if r.camera == nil {
+		return render()
+	}
+
+	factor := [2]float64{r.parallax[0] * float64(parallaxX), r.parallax[1] * float64(parallaxY)}
+	shift := image.Pt(
+		int(math.Round((r.camera.CenterX-r.m.ParallaxOriginX)*(1-factor[0]))),
+		int(math.Round((r.camera.CenterY-r.m.ParallaxOriginY)*(1-factor[1]))),
+	)
+
+	// Drawing into a view of the result with shifted bounds moves everything
+	// drawn, relative to the offset the result already has.
+	result, prevFactor, prevShift := r.Result, r.parallax, r.shift
+	r.Result = &image.NRGBA{
+		Pix:    result.Pix,
+		Stride: result.Stride,
+		Rect:   result.Rect.Sub(shift.Sub(prevShift)),
+	}
+	r.parallax, r.shift = factor, shift
+	defer func() {
+		r.Result, r.parallax, r.shift = result, prevFactor, prevShift
+	}()
+
+	return render()
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+	"testing/fstest"
+)
+
+// parallaxMap is a 40x4 pixel strip of red, green and blue tiles, repeating.
+const parallaxMap = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="10" height="1" tilewidth="4" tileheight="4"%s>
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="3" columns="3">
+  <image source="tiles.png" width="12" height="4"/>
+ </tileset>
+ %s
+</map>`
+
+const parallaxLayer = `<layer id="1" name="strip" width="10" height="1"%s><data encoding="csv">1,2,3,1,2,3,1,2,3,1</data></layer>`
+
+func TestRenderer_RenderCamera(t *testing.T) {
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 4, red, green, blue)}}
+	camera := Camera{CenterX: 20, CenterY: 2, Width: 8, Height: 4}
+
+	tests := []struct {
+		name     string
+		mapAttrs string
+		layers   string
+		camera   Camera
+		want     map[int]color.NRGBA
+	}{
+		{
+			name:   "no parallax",
+			layers: fmt.Sprintf(parallaxLayer, ""),
+			camera: camera,
+			want:   map[int]color.NRGBA{0: green, 3: green, 4: blue, 7: blue},
+		},
+		{
+			name:   "parallax",
+			layers: fmt.Sprintf(parallaxLayer, ` parallaxx="0.5"`),
+			camera: camera,
+			want:   map[int]color.NRGBA{0: green, 2: blue, 5: blue, 6: red},
+		},
+		{
+			name:     "parallax origin",
+			mapAttrs: ` parallaxoriginx="20"`,
+			layers:   fmt.Sprintf(parallaxLayer, ` parallaxx="0.5"`),
+			camera:   camera,
+			want:     map[int]color.NRGBA{0: green, 4: blue},
+		},
+		{
+			name:   "compounded through groups",
+			layers: `<group id="2" name="g" parallaxx="0.5">` + fmt.Sprintf(parallaxLayer, ` parallaxx="0.5"`) + `</group>`,
+			camera: camera,
+			want:   map[int]color.NRGBA{0: red, 2: red, 3: green, 7: blue},
+		},
+		{
+			name:   "zoom",
+			layers: fmt.Sprintf(parallaxLayer, ""),
+			camera: Camera{CenterX: 20, CenterY: 2, Width: 8, Height: 4, Zoom: 2},
+			want:   map[int]color.NRGBA{0: green, 3: green, 4: blue, 7: blue},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := loadTestMap(t, fmt.Sprintf(parallaxMap, tt.mapAttrs, tt.layers), files)
+			if err := r.RenderCamera(tt.camera); err != nil {
+				t.Fatal(err)
+			}
+			if b := r.Result.Bounds(); b != image.Rect(0, 0, tt.camera.Width, tt.camera.Height) {
+				t.Fatalf("result bounds = %v", b)
+			}
+			for x, want := range tt.want {
+				if c := r.Result.NRGBAAt(x, 1); c != want {
+					t.Errorf("pixel %d = %v, want %v", x, c, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderer_RenderCamera_Invalid(t *testing.T) {
+	r := loadTestMap(t, fmt.Sprintf(parallaxMap, "", ""), nil)
+	for _, c := range []Camera{{Width: 0, Height: 4}, {Width: 4, Height: 4, Zoom: -1}} {
+		if err := r.RenderCamera(c); !errors.Is(err, ErrInvalidCamera) {
+			t.Errorf("RenderCamera(%+v) error = %v, want %v", c, err, ErrInvalidCamera)
+		}
+	}
+}
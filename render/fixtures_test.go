@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// solidTiles returns a PNG tileset image with a row of size x size tiles,
+// each filled with one of the given colors.
+func solidTiles(t *testing.T, size int, colors ...color.Color) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, size*len(colors), size))
+	for i, c := range colors {
+		for y := 0; y < size; y++ {
+			for x := i * size; x < (i+1)*size; x++ {
+				img.Set(x, y, c)
+			}
+		}
+	}
+
+	return encodePNG(t, img)
+}
+
+// encodePNG returns img encoded as PNG.
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// loadTestMap loads a map from TMX source and returns a renderer for it that
+// reads images from files.
+func loadTestMap(t *testing.T, tmx string, files fstest.MapFS) *Renderer {
+	t.Helper()
+
+	m, err := tiled.LoadReader(".", strings.NewReader(tmx), tiled.WithFileSystem(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRendererWithFileSystem(m, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
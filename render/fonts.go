@@ -0,0 +1,108 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+// FontProvider is the interface implemented by objects that provide the font
+// faces text objects are drawn with.
+type FontProvider interface {
+	// FontFace returns a face for the given font family, size in pixels and
+	// style, or nil if the family isn't available, in which case the
+	// bundled Go fonts are used instead.
+	FontFace(family string, size float64, bold, italic bool) (font.Face, error)
+}
+
+// GoFontProvider provides faces of the Go fonts, which are bundled with the
+// renderer so that text can be drawn without any fonts installed. Families
+// containing "mono" use Go Mono, every other family uses Go Regular.
+type GoFontProvider struct{}
+
+var (
+	goFontsOnce sync.Once
+	goFonts     map[string]*opentype.Font
+	goFontsErr  error
+)
+
+// FontFace returns a face of the Go font closest to the given family and
+// style. It never returns nil.
+func (GoFontProvider) FontFace(family string, size float64, bold, italic bool) (font.Face, error) {
+	goFontsOnce.Do(func() {
+		goFonts = make(map[string]*opentype.Font)
+		for name, data := range map[string][]byte{
+			"regular":        goregular.TTF,
+			"bold":           gobold.TTF,
+			"italic":         goitalic.TTF,
+			"bolditalic":     gobolditalic.TTF,
+			"monoregular":    gomono.TTF,
+			"monobold":       gomonobold.TTF,
+			"monoitalic":     gomonoitalic.TTF,
+			"monobolditalic": gomonobolditalic.TTF,
+		} {
+			f, err := opentype.Parse(data)
+			if err != nil {
+				goFontsErr = err
+				return
+			}
+			goFonts[name] = f
+		}
+	})
+	if goFontsErr != nil {
+		return nil, goFontsErr
+	}
+
+	var name string
+	if strings.Contains(strings.ToLower(family), "mono") {
+		name = "mono"
+	}
+	switch {
+	case bold && italic:
+		name += "bolditalic"
+	case bold:
+		name += "bold"
+	case italic:
+		name += "italic"
+	default:
+		name += "regular"
+	}
+
+	return opentype.NewFace(goFonts[name], &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+}
@@ -189,9 +189,9 @@ func (e *HexagonalRendererEngine) PixelToScreenCoords(x, y float64) (float64, fl
 	return x, y
 }
 
-// GetObjectAnchor returns the bottom-left point of a tile object's image. Tiled's
+// GetObjectAnchor returns the bottom-left corner of a tile object's image. Tiled's
 // default tile-object alignment is bottom-center only for isometric maps; every
 // other orientation, including hexagonal, defaults to bottom-left.
 func (e *HexagonalRendererEngine) GetObjectAnchor(imgSize image.Point) image.Point {
-	return image.Pt(0, imgSize.Y-1)
+	return image.Pt(0, imgSize.Y)
 }
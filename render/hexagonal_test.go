@@ -129,7 +129,7 @@ func TestHexagonalRendererEngine_GetObjectAnchor(t *testing.T) {
 	e := &HexagonalRendererEngine{}
 	e.Init(&tiled.Map{TileWidth: 32, TileHeight: 32, HexSideLength: 16, StaggerAxis: tiled.AxisY})
 
-	if got, want := e.GetObjectAnchor(image.Pt(10, 6)), image.Pt(0, 5); got != want {
+	if got, want := e.GetObjectAnchor(image.Pt(10, 6)), image.Pt(0, 6); got != want {
 		t.Errorf("GetObjectAnchor(10, 6) = %v, want %v", got, want)
 	}
 }
@@ -109,8 +109,8 @@ func (e *IsometricRendererEngine) PixelToScreenCoords(x, y float64) (float64, fl
 	return sx, sy
 }
 
-// GetObjectAnchor returns the bottom-center point of a tile object's image, per
-// the Tiled spec's isometric alignment rule.
+// GetObjectAnchor returns the bottom-center point of a tile object's image, on
+// its bottom edge, per the Tiled spec's isometric alignment rule.
 func (e *IsometricRendererEngine) GetObjectAnchor(imgSize image.Point) image.Point {
-	return image.Pt(imgSize.X/2, imgSize.Y-1)
+	return image.Pt(imgSize.X/2, imgSize.Y)
 }
@@ -125,13 +125,13 @@ func TestGetObjectAnchor(t *testing.T) {
 
 	ortho := &OrthogonalRendererEngine{}
 	ortho.Init(&tiled.Map{})
-	if got, want := ortho.GetObjectAnchor(imgSize), image.Pt(0, 5); got != want {
+	if got, want := ortho.GetObjectAnchor(imgSize), image.Pt(0, 6); got != want {
 		t.Errorf("OrthogonalRendererEngine.GetObjectAnchor(%v) = %v, want %v", imgSize, got, want)
 	}
 
 	iso := &IsometricRendererEngine{}
 	iso.Init(&tiled.Map{})
-	if got, want := iso.GetObjectAnchor(imgSize), image.Pt(5, 5); got != want {
+	if got, want := iso.GetObjectAnchor(imgSize), image.Pt(5, 6); got != want {
 		t.Errorf("IsometricRendererEngine.GetObjectAnchor(%v) = %v, want %v", imgSize, got, want)
 	}
 }
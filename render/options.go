@@ -0,0 +1,86 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"image"
+	"io"
+	"strings"
+)
+
+// RendererOption is used with NewRenderer and NewRendererWithFileSystem to
+// pass additional options.
+type RendererOption func(*Renderer)
+
+// WithObjectScale returns an option to scale the line width of shape objects
+// and the size of point markers, which are otherwise drawn at their default
+// size regardless of the map's tile size. Non-positive scales are ignored.
+func WithObjectScale(scale float64) RendererOption {
+	return func(r *Renderer) {
+		if scale > 0 {
+			r.objectScale = scale
+		}
+	}
+}
+
+// WithFontProvider returns an option to load the fonts of text objects from
+// provider, falling back to the Go fonts for families it doesn't have. The
+// faces it returns are only used to draw one text at a time, even by
+// RenderMapParallel and the other methods rendering parts of the map
+// concurrently, so they needn't be safe for concurrent use.
+func WithFontProvider(provider FontProvider) RendererOption {
+	return func(r *Renderer) {
+		r.fonts = provider
+	}
+}
+
+// WithTileImageCache returns an option to cache images in cache, which may be
+// shared with other renderers, instead of a cache of the renderer's own.
+func WithTileImageCache(cache *TileImageCache) RendererOption {
+	return func(r *Renderer) {
+		r.cache = cache
+	}
+}
+
+// ImageDecoder decodes an image file used by a map. Images without practical
+// bounds, such as image.Uniform, are accepted but never cached.
+type ImageDecoder func(r io.Reader) (image.Image, error)
+
+// WithImageDecoder returns an option to decode the tileset and image layer
+// images with the file extension ext, such as ".bmp", using decode. Other
+// images are decoded with the formats registered with the image package,
+// which are PNG, JPEG and GIF unless more are imported. Tiled also accepts
+// BMP, TIFF and WebP images, which the golang.org/x/image/bmp, tiff and webp
+// packages can decode.
+func WithImageDecoder(ext string, decode ImageDecoder) RendererOption {
+	return func(r *Renderer) {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if r.decoders == nil {
+			r.decoders = make(map[string]ImageDecoder)
+		}
+		r.decoders[ext] = decode
+	}
+}
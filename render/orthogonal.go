@@ -76,8 +76,8 @@ func (e *OrthogonalRendererEngine) PixelToScreenCoords(x, y float64) (float64, f
 	return x, y
 }
 
-// GetObjectAnchor returns the bottom-left point of a tile object's image, per the
+// GetObjectAnchor returns the bottom-left corner of a tile object's image, per the
 // Tiled spec's orthogonal alignment rule.
 func (e *OrthogonalRendererEngine) GetObjectAnchor(imgSize image.Point) image.Point {
-	return image.Pt(0, imgSize.Y-1)
+	return image.Pt(0, imgSize.Y)
 }
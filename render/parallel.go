@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"context"
+	"image"
+	"runtime"
+	"sync"
+)
+
+// ParallelOptions are the options for rendering a map in parallel.
+type ParallelOptions struct {
+	// Workers is the number of bands rendered at the same time. Defaults to
+	// runtime.GOMAXPROCS(0).
+	Workers int
+	// Bands is the number of horizontal bands the result is split into, which
+	// is capped at its height. Every band goes through all tiles of the map,
+	// skipping those outside of it, and draws all objects. Defaults to four
+	// times the number of workers.
+	Bands int
+	// Progress is called after each band is rendered, with the number of
+	// bands rendered so far and their total. It isn't called concurrently.
+	Progress func(done, total int)
+}
+
+// RenderMapParallel clears the result and renders the whole map like
+// RenderMap, but splits the result into horizontal bands that are rendered
+// concurrently, by renderers sharing the tile image cache. Tiles and objects
+// reaching into a band from outside of it are drawn clipped to the band, so
+// the result is the same as RenderMap's. The font provider set up with
+// WithFontProvider and the image decoders are called concurrently, but text
+// is drawn by one band at a time, so the faces the provider returns may be
+// shared. Rendering
+// stops with ctx's error once ctx is done, leaving the result partially
+// rendered.
+func (r *Renderer) RenderMapParallel(ctx context.Context, opts *ParallelOptions) error {
+	var o ParallelOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Workers <= 0 {
+		o.Workers = runtime.GOMAXPROCS(0)
+	}
+	if o.Bands <= 0 {
+		o.Bands = 4 * o.Workers
+	}
+
+	// External tilesets are loaded when their tiles are first looked up, so
+	// load them before that can happen in several goroutines at once.
+	for _, ts := range r.m.Tilesets {
+		if _, err := r.m.TileGIDToTile(ts.FirstGID); err != nil {
+			return err
+		}
+	}
+
+	r.Clear()
+	bounds := r.Result.Bounds()
+	o.Bands = max(min(o.Bands, bounds.Dy()), 1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	bands := make(chan image.Rectangle, o.Bands)
+	for i := 0; i < o.Bands; i++ {
+		bands <- image.Rect(
+			bounds.Min.X, bounds.Min.Y+i*bounds.Dy()/o.Bands,
+			bounds.Max.X, bounds.Min.Y+(i+1)*bounds.Dy()/o.Bands,
+		)
+	}
+	close(bands)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		done     int
+		firstErr error
+	)
+	for i := 0; i < min(o.Workers, o.Bands); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for band := range bands {
+				if ctx.Err() != nil {
+					return
+				}
+				err := r.partRenderer(ctx, r.Result.SubImage(band).(*image.NRGBA)).renderMapLayers()
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					cancel()
+				} else {
+					done++
+					if o.Progress != nil {
+						o.Progress(done, o.Bands)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// partRenderer returns a renderer of the same map that renders the part of it
+// within the bounds of result into result. It shares the tile image cache,
+// which is safe to use concurrently, and the lock text is drawn under, but
+// none of the state that isn't.
+func (r *Renderer) partRenderer(ctx context.Context, result *image.NRGBA) *Renderer {
+	return &Renderer{
+		m:           r.m,
+		Result:      result,
+		engine:      r.engine,
+		fs:          r.fs,
+		cache:       r.cache,
+		time:        r.time,
+		objectScale: r.objectScale,
+		fonts:       r.fonts,
+		textMu:      r.textMu,
+		parallax:    [2]float64{1, 1},
+		decoders:    r.decoders,
+		cull:        true,
+		ctx:         ctx,
+	}
+}
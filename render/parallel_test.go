@@ -0,0 +1,228 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lafriks/go-tiled"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// parallelMap has tall tiles and objects reaching across bands, a blended
+// group and text.
+const parallelMap = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="%s" renderorder="right-down" width="6" height="6" tilewidth="8" tileheight="8">
+ <tileset firstgid="1" name="tall" tilewidth="8" tileheight="20" tilecount="2" columns="2">
+  <tileoffset x="0" y="3"/>
+  <image source="tall.png" width="16" height="20"/>
+ </tileset>
+ <layer id="1" name="ground" width="6" height="6"><data encoding="csv">
+1,2,1,2,1,2,
+2,1,2,1,2,1,
+1,0,0,2,0,1,
+0,2,1,0,2,0,
+1,2,0,1,0,2,
+2,0,2,0,1,1
+</data></layer>
+ <group id="2" name="group" opacity="0.7" mode="multiply" offsety="5">
+  <layer id="3" name="overlay" width="6" height="6" tintcolor="#8080ff"><data encoding="csv">
+0,0,1,0,0,0,
+0,2,0,0,1,0,
+0,0,0,0,0,0,
+1,0,0,2,0,0,
+0,0,0,0,0,2,
+0,1,0,0,0,0
+</data></layer>
+ </group>
+ <objectgroup id="4" name="objects" color="#00ff00">
+  <object id="1" x="3.3" y="4.7" width="37.1" height="29.9"/>
+  <object id="2" x="10.5" y="7.25">
+   <polygon points="0,0 20.3,13.1 -4.2,30.7"/>
+  </object>
+  <object id="3" x="5" y="5" width="30" height="30">
+   <ellipse/>
+  </object>
+  <object id="4" gid="2" x="20" y="30" width="12" height="24" rotation="30"/>
+  <object id="5" x="2" y="20" width="40" height="20">
+   <text wrap="1">Hello parallel world</text>
+  </object>
+ </objectgroup>
+</map>`
+
+func loadParallelMap(t *testing.T, orientation string) *Renderer {
+	t.Helper()
+
+	// A gradient, translucent at the top, so that misplaced pixels show.
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 16; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 16), uint8(y * 12), 128, uint8(min(255, 80+y*20))})
+		}
+	}
+	files := fstest.MapFS{"tall.png": {Data: encodePNG(t, img)}}
+	return loadTestMap(t, fmt.Sprintf(parallelMap, orientation), files)
+}
+
+func TestRenderer_RenderMapParallel(t *testing.T) {
+	for _, orientation := range []string{"orthogonal", "isometric", "staggered", "hexagonal"} {
+		t.Run(orientation, func(t *testing.T) {
+			r := loadParallelMap(t, orientation)
+			if err := r.RenderMap(); err != nil {
+				t.Fatal(err)
+			}
+			want := r.Result
+
+			for _, opts := range []ParallelOptions{
+				{Workers: 1, Bands: 1},
+				{Workers: 3, Bands: 7},
+				{Workers: 4, Bands: 1000},
+			} {
+				r := loadParallelMap(t, orientation)
+				var progress []int
+				opts.Progress = func(done, total int) {
+					progress = append(progress, done)
+					if total != min(opts.Bands, want.Bounds().Dy()) {
+						t.Errorf("%+v: progress total = %d", opts, total)
+					}
+				}
+				if err := r.RenderMapParallel(context.Background(), &opts); err != nil {
+					t.Fatal(err)
+				}
+
+				if r.Result.Bounds() != want.Bounds() || !bytes.Equal(r.Result.Pix, want.Pix) {
+					t.Errorf("workers %d, bands %d: result differs from RenderMap", opts.Workers, opts.Bands)
+				}
+				if len(progress) != min(opts.Bands, want.Bounds().Dy()) || progress[len(progress)-1] != len(progress) {
+					t.Errorf("workers %d, bands %d: progress %v", opts.Workers, opts.Bands, progress)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderer_RenderMapParallel_Assets(t *testing.T) {
+	for _, name := range []string{"test_render_objects.tmx", "test_isometric.tmx", "hex.tmx", "staggered.tmx"} {
+		t.Run(name, func(t *testing.T) {
+			m, err := tiled.LoadFile("../assets/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := NewRenderer(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.RenderMap(); err != nil {
+				t.Fatal(err)
+			}
+			want := r.Result
+
+			if err := r.RenderMapParallel(context.Background(), &ParallelOptions{Bands: 13}); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(r.Result.Pix, want.Pix) {
+				t.Error("result differs from RenderMap")
+			}
+		})
+	}
+}
+
+func TestRenderer_RenderMapParallel_Cancel(t *testing.T) {
+	r := loadParallelMap(t, "orthogonal")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := r.RenderMapParallel(ctx, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("RenderMapParallel() = %v, want %v", err, context.Canceled)
+	}
+}
+
+// exclusiveFace is a face that records whether it was ever used by several
+// goroutines at once.
+type exclusiveFace struct {
+	font.Face
+	users      atomic.Int32
+	concurrent atomic.Bool
+}
+
+func (f *exclusiveFace) use() func() {
+	if f.users.Add(1) > 1 {
+		f.concurrent.Store(true)
+	}
+	// Give other goroutines the chance to use the face meanwhile.
+	runtime.Gosched()
+	return func() { f.users.Add(-1) }
+}
+
+func (f *exclusiveFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	defer f.use()()
+	return f.Face.Glyph(dot, r)
+}
+
+func (f *exclusiveFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	defer f.use()()
+	return f.Face.GlyphBounds(r)
+}
+
+func (f *exclusiveFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	defer f.use()()
+	return f.Face.GlyphAdvance(r)
+}
+
+// sharedFaceProvider provides the same face for every font.
+type sharedFaceProvider struct {
+	face *exclusiveFace
+}
+
+func (p sharedFaceProvider) FontFace(string, float64, bool, bool) (font.Face, error) {
+	return p.face, nil
+}
+
+func TestRenderer_RenderMapParallel_SharedFace(t *testing.T) {
+	goFace, err := GoFontProvider{}.FontFace("", 16, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face := &exclusiveFace{Face: goFace}
+
+	r := loadParallelMap(t, "orthogonal")
+	WithFontProvider(sharedFaceProvider{face: face})(r)
+	// The text object reaches across many bands, which all draw it.
+	if err := r.RenderMapParallel(context.Background(), &ParallelOptions{Workers: 4, Bands: 48}); err != nil {
+		t.Fatal(err)
+	}
+	if face.concurrent.Load() {
+		t.Error("face shared by the font provider was used concurrently")
+	}
+}
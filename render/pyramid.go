@@ -0,0 +1,348 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/lafriks/go-tiled"
+	xdraw "golang.org/x/image/draw"
+)
+
+// ErrInvalidZoom represents an error in the zoom levels of a tile pyramid.
+var ErrInvalidZoom = errors.New("tiled/render: invalid zoom levels")
+
+// DefaultPyramidTileSize is the size of the tiles of a tile pyramid when
+// PyramidOptions.TileSize isn't set.
+const DefaultPyramidTileSize = 256
+
+// TileWriter creates the files of a tile pyramid.
+type TileWriter interface {
+	// Create creates the file with the given slash-separated name, such as
+	// "3/1/2.png", replacing it if it exists.
+	Create(name string) (io.WriteCloser, error)
+}
+
+// NewDirTileWriter returns a TileWriter that writes files into directory
+// dir, creating the directories of the files as needed.
+func NewDirTileWriter(dir string) TileWriter {
+	return dirTileWriter(dir)
+}
+
+type dirTileWriter string
+
+func (d dirTileWriter) Create(name string) (io.WriteCloser, error) {
+	path := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+// NewZipTileWriter returns a TileWriter that adds files to zip archive zw,
+// which is left open. Each file must be closed before the next one is created.
+func NewZipTileWriter(zw *zip.Writer) TileWriter {
+	return zipTileWriter{zw}
+}
+
+type zipTileWriter struct {
+	zw *zip.Writer
+}
+
+func (z zipTileWriter) Create(name string) (io.WriteCloser, error) {
+	// PNG files are already compressed.
+	w, err := z.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	return nopWriteCloser{w}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// PyramidOptions are the options for exporting a tile pyramid.
+type PyramidOptions struct {
+	// TileSize is the width and height of the tiles in pixels. Defaults to
+	// DefaultPyramidTileSize.
+	TileSize int
+	// MinZoom is the lowest zoom level written. Defaults to 0.
+	MinZoom int
+	// MaxZoom is the highest zoom level written, at which the map is shown at
+	// its own size, and halved at every level below. When zero, it defaults to
+	// the lowest level at which the whole map fits in a single tile at zoom
+	// level 0.
+	MaxZoom int
+	// Interpolator scales down the tiles of higher zoom levels into those of
+	// lower ones. Defaults to draw.ApproxBiLinear from golang.org/x/image/draw.
+	Interpolator xdraw.Interpolator
+}
+
+// SavePyramid renders the map as a tile pyramid for web map viewers such as
+// Leaflet, writing PNG files named "z/x/y.png" for zoom level z and tile
+// column x and row y, counted from the top-left corner of the map. Only the
+// tiles of the highest zoom level that may have some of the map's contents
+// are rendered, the tiles of the lower levels are scaled down from those, and
+// tiles without any visible pixels aren't written. The result is left as it
+// is.
+func (r *Renderer) SavePyramid(w TileWriter, opts *PyramidOptions) error {
+	var o PyramidOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.TileSize <= 0 {
+		o.TileSize = DefaultPyramidTileSize
+	}
+	if o.Interpolator == nil {
+		o.Interpolator = xdraw.ApproxBiLinear
+	}
+
+	bounds := r.engine.GetFinalImageSize()
+	if o.MaxZoom == 0 {
+		for size := max(bounds.Dx(), bounds.Dy()); o.TileSize<<o.MaxZoom < size; {
+			o.MaxZoom++
+		}
+	}
+	if o.MinZoom < 0 || o.MinZoom > o.MaxZoom || o.MaxZoom > 30 {
+		return ErrInvalidZoom
+	}
+
+	p := &pyramid{r: r, w: w, opts: o, bounds: bounds}
+	nx, _ := p.tiles(o.MaxZoom)
+	content, err := r.contentTiles(bounds, o.TileSize, nx)
+	if err != nil {
+		return err
+	}
+	p.content = content
+
+	nx, ny := p.tiles(o.MinZoom)
+	for y := 0; y < ny; y++ {
+		for x := 0; x < nx; x++ {
+			if _, err := p.tile(o.MinZoom, x, y); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pyramid is a tile pyramid being written.
+type pyramid struct {
+	r      *Renderer
+	w      TileWriter
+	opts   PyramidOptions
+	bounds image.Rectangle
+	// content marks the tiles of the highest zoom level that may have some
+	// of the map's contents, row by row.
+	content []bool
+}
+
+// tiles returns the number of columns and rows of tiles at zoom level z.
+func (p *pyramid) tiles(z int) (int, int) {
+	size := p.opts.TileSize << (p.opts.MaxZoom - z)
+	return (p.bounds.Dx() + size - 1) / size, (p.bounds.Dy() + size - 1) / size
+}
+
+// tile returns the image of tile (x, y) at zoom level z, and writes it along
+// with the tiles of higher levels it is made of, or returns nil if the tile
+// is empty.
+func (p *pyramid) tile(z, x, y int) (*image.NRGBA, error) {
+	nx, ny := p.tiles(z)
+	if x >= nx || y >= ny {
+		return nil, nil
+	}
+
+	size := p.opts.TileSize
+	var img *image.NRGBA
+	if z == p.opts.MaxZoom {
+		if !p.content[y*nx+x] {
+			return nil, nil
+		}
+		img = image.NewNRGBA(image.Rect(0, 0, size, size))
+		if err := p.r.RenderTo(img, &RenderToOptions{Offset: image.Pt(-x*size, -y*size)}); err != nil {
+			return nil, err
+		}
+	} else {
+		var children *image.NRGBA
+		for i := 0; i < 4; i++ {
+			dx, dy := i%2, i/2
+			child, err := p.tile(z+1, 2*x+dx, 2*y+dy)
+			if err != nil {
+				return nil, err
+			}
+			if child == nil {
+				continue
+			}
+			if children == nil {
+				children = image.NewNRGBA(image.Rect(0, 0, 2*size, 2*size))
+			}
+			pos := image.Pt(dx*size, dy*size)
+			draw.Draw(children, child.Bounds().Add(pos), child, image.Point{}, draw.Src)
+		}
+		if children == nil {
+			return nil, nil
+		}
+		img = image.NewNRGBA(image.Rect(0, 0, size, size))
+		p.opts.Interpolator.Scale(img, img.Bounds(), children, children.Bounds(), draw.Src, nil)
+	}
+
+	if isTransparent(img) {
+		return nil, nil
+	}
+	f, err := p.w.Create(fmt.Sprintf("%d/%d/%d.png", z, x, y))
+	if err != nil {
+		return nil, err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return img, f.Close()
+}
+
+// isTransparent reports whether all pixels of img are fully transparent.
+func isTransparent(img *image.NRGBA) bool {
+	for i := 3; i < len(img.Pix); i += 4 {
+		if img.Pix[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// contentTiles returns which of the tiles of the given size, nx per row,
+// covering bounds may have some of the map's visible contents, judging by
+// where its tiles, objects and images are drawn.
+func (r *Renderer) contentTiles(bounds image.Rectangle, size, nx int) ([]bool, error) {
+	ny := (bounds.Dy() + size - 1) / size
+	content := make([]bool, nx*ny)
+	all := func() {
+		for i := range content {
+			content[i] = true
+		}
+	}
+	mark := func(rect image.Rectangle) {
+		rect = rect.Intersect(bounds).Sub(bounds.Min)
+		if rect.Empty() {
+			return
+		}
+		for y := rect.Min.Y / size; y <= (rect.Max.Y-1)/size; y++ {
+			for x := rect.Min.X / size; x <= (rect.Max.X-1)/size; x++ {
+				content[y*nx+x] = true
+			}
+		}
+	}
+	if r.m.BackgroundColor != nil {
+		all()
+		return content, nil
+	}
+
+	m := r.cullMargin()
+	var walk func(nodes []tiled.LayerNode, offset image.Point) error
+	walk = func(nodes []tiled.LayerNode, offset image.Point) error {
+		for _, n := range nodes {
+			if !n.Visible() {
+				continue
+			}
+			switch {
+			case n.Layer != nil:
+				off := offset.Add(roundOffset(n.Layer.OffsetX, n.Layer.OffsetY))
+				for i, tile := range n.Layer.Tiles {
+					if tile.IsNil() {
+						continue
+					}
+					pos := r.engine.GetTilePosition(i%r.m.Width, i/r.m.Width, image.Pt(m, m)).Add(off)
+					mark(image.Rect(pos.X-m, pos.Y-m, pos.X+2*m, pos.Y+2*m))
+				}
+			case n.ObjectGroup != nil:
+				for _, o := range n.ObjectGroup.Objects {
+					if !o.Visible {
+						continue
+					}
+					if o.Text != nil && (o.Width == 0 || o.Height == 0) {
+						// The size of the text is only known once it is laid out.
+						all()
+						return nil
+					}
+					mark(r.objectArea(n.ObjectGroup, o).Add(offset))
+				}
+			case n.ImageLayer != nil:
+				l := n.ImageLayer
+				if l.Image == nil || l.Image.Source == "" {
+					continue
+				}
+				if l.RepeatX || l.RepeatY {
+					all()
+					return nil
+				}
+				img, err := r.loadImage(r.m.GetFileFullPath(l.Image.Source), l.Image.Trans)
+				if err != nil {
+					return err
+				}
+				pos := offset.Add(roundOffset(l.OffsetX, l.OffsetY)).Add(image.Pt(l.X, l.Y))
+				mark(img.Bounds().Sub(img.Bounds().Min).Add(pos))
+			case n.Group != nil:
+				off := offset.Add(roundOffset(n.Group.OffsetX, n.Group.OffsetY))
+				if err := walk(n.Group.OrderedLayers(), off); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(r.m.OrderedLayers(), image.Point{}); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// objectArea returns an area that the object is drawn within, with a margin
+// for outlines, markers and the rotation of tile images.
+func (r *Renderer) objectArea(layer *tiled.ObjectGroup, o *tiled.Object) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range o.Shape().Vertices() {
+		x, y := r.engine.PixelToScreenCoords(p.X, p.Y)
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+	pad := math.Max(o.Width, o.Height) + (shapeLineWidth+shapePointRadius)*r.objectScale + float64(r.cullMargin())
+	return image.Rect(
+		int(math.Floor(minX+layer.OffsetX-pad)), int(math.Floor(minY+layer.OffsetY-pad)),
+		int(math.Ceil(maxX+layer.OffsetX+pad)), int(math.Ceil(maxY+layer.OffsetY+pad)),
+	)
+}
@@ -0,0 +1,193 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/fstest"
+)
+
+// memTileWriter keeps the files of a tile pyramid in memory.
+type memTileWriter map[string]*bytes.Buffer
+
+func (m memTileWriter) Create(name string) (io.WriteCloser, error) {
+	b := &bytes.Buffer{}
+	m[name] = b
+	return nopWriteCloser{b}, nil
+}
+
+func (m memTileWriter) names() []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sparseMap is 64x64 pixels, with tiles in two opposite corners and a point
+// object in the middle of the top edge.
+const sparseMap = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="8" height="8" tilewidth="8" tileheight="8">
+ <tileset firstgid="1" name="tiles" tilewidth="8" tileheight="8" tilecount="1" columns="1">
+  <image source="tiles.png" width="8" height="8"/>
+ </tileset>
+ <layer id="1" name="tiles" width="8" height="8"><data encoding="csv">
+1,0,0,0,0,0,0,0,
+0,0,0,0,0,0,0,0,
+0,0,0,0,0,0,0,0,
+0,0,0,0,0,0,0,0,
+0,0,0,0,0,0,0,0,
+0,0,0,0,0,0,0,0,
+0,0,0,0,0,0,0,0,
+0,0,0,0,0,0,0,1
+</data></layer>
+ <objectgroup id="2" name="objects"%s>
+  <object id="1" x="40" y="4"><point/></object>
+ </objectgroup>
+</map>`
+
+func TestRenderer_SavePyramid(t *testing.T) {
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 8, red)}}
+
+	tests := []struct {
+		name  string
+		attrs string
+		opts  PyramidOptions
+		want  []string
+	}{
+		{
+			name: "default zoom levels",
+			opts: PyramidOptions{TileSize: 16},
+			want: []string{
+				"0/0/0.png",
+				"1/0/0.png", "1/1/0.png", "1/1/1.png",
+				"2/0/0.png", "2/2/0.png", "2/3/3.png",
+			},
+		},
+		{
+			name:  "hidden objects",
+			attrs: ` visible="0"`,
+			opts:  PyramidOptions{TileSize: 16, MinZoom: 1},
+			want:  []string{"1/0/0.png", "1/1/1.png", "2/0/0.png", "2/3/3.png"},
+		},
+		{
+			name:  "native size in a single tile",
+			attrs: ` visible="0"`,
+			opts:  PyramidOptions{},
+			want:  []string{"0/0/0.png"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := loadTestMap(t, fmt.Sprintf(sparseMap, tt.attrs), files)
+			w := memTileWriter{}
+			if err := r.SavePyramid(w, &tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.names(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderer_SavePyramid_Tiles(t *testing.T) {
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 8, red)}}
+	r := loadTestMap(t, fmt.Sprintf(sparseMap, ` visible="0"`), files)
+	w := memTileWriter{}
+	if err := r.SavePyramid(w, &PyramidOptions{TileSize: 16}); err != nil {
+		t.Fatal(err)
+	}
+
+	decode := func(name string) *image.NRGBA {
+		t.Helper()
+		img, err := png.Decode(w[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img.(*image.NRGBA)
+	}
+
+	// The bottom-right tile of the highest zoom level has the map tile in
+	// its bottom-right quarter, and the lowest level shows it scaled down.
+	checkPixels(t, "2/3/3", decode("2/3/3.png"), map[image.Point]color.NRGBA{
+		{7, 7}: {}, {8, 8}: red, {15, 15}: red,
+	})
+	checkPixels(t, "0/0/0", decode("0/0/0.png"), map[image.Point]color.NRGBA{
+		{0, 0}: red, {1, 1}: red, {2, 2}: {}, {13, 13}: {}, {14, 14}: red, {15, 15}: red,
+	})
+
+	if err := r.SavePyramid(w, &PyramidOptions{MinZoom: 3, MaxZoom: 2}); err != ErrInvalidZoom {
+		t.Errorf("SavePyramid() = %v, want %v", err, ErrInvalidZoom)
+	}
+}
+
+func TestTileWriters(t *testing.T) {
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 8, red)}}
+	r := loadTestMap(t, fmt.Sprintf(sparseMap, ` visible="0"`), files)
+	opts := &PyramidOptions{TileSize: 32}
+
+	dir := t.TempDir()
+	if err := r.SavePyramid(NewDirTileWriter(dir), opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"0/0/0.png", "1/0/0.png", "1/1/1.png"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Error(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := r.SavePyramid(NewZipTileWriter(zw), opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	if want := []string{"0/0/0.png", "1/0/0.png", "1/1/1.png"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("zip files = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// RenderVisibleImageLayers renders all visible image layers.
+func (r *Renderer) RenderVisibleImageLayers() error {
+	for i := range r.m.ImageLayers {
+		if !r.m.ImageLayers[i].Visible {
+			continue
+		}
+
+		if err := r.RenderImageLayer(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderImageLayer renders single image layer.
+func (r *Renderer) RenderImageLayer(id int) error {
+	if id >= len(r.m.ImageLayers) {
+		return ErrOutOfBounds
+	}
+	return r._renderImageLayer(r.m.ImageLayers[id])
+}
+
+// RenderGroupImageLayer renders single image layer in a certain group.
+func (r *Renderer) RenderGroupImageLayer(groupID, imageLayerID int) error {
+	if groupID >= len(r.m.Groups) {
+		return ErrOutOfBounds
+	}
+	group := r.m.Groups[groupID]
+
+	if imageLayerID >= len(group.ImageLayers) {
+		return ErrOutOfBounds
+	}
+	return r._renderImageLayer(group.ImageLayers[imageLayerID])
+}
+
+// loadImage returns the image at the given path, decoded with the decoder set
+// up for its extension if any, with pixels of the trans color made
+// transparent, caching it for later renders.
+func (r *Renderer) loadImage(path string, trans *tiled.HexColor) (image.Image, error) {
+	return r.loadSourceImage(newSourceImageKey(path, trans))
+}
+
+// newSourceImageKey returns the key the image at path is cached with, with
+// pixels of the trans color made transparent.
+func newSourceImageKey(path string, trans *tiled.HexColor) sourceImageKey {
+	key := sourceImageKey{path: path}
+	if trans != nil {
+		key.trans, key.keyed = color.NRGBAModel.Convert(trans).(color.NRGBA), true
+	}
+	return key
+}
+
+// loadSourceImage returns the image identified by key, decoding it if it
+// isn't cached.
+func (r *Renderer) loadSourceImage(key sourceImageKey) (image.Image, error) {
+	return r.cache.load(key, func() (image.Image, error) {
+		f, err := r.open(key.path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		var img image.Image
+		if decode, ok := r.decoders[strings.ToLower(filepath.Ext(key.path))]; ok {
+			img, err = decode(f)
+		} else {
+			img, _, err = image.Decode(f)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if key.keyed {
+			img = colorKey(img, key.trans)
+		}
+		return img, nil
+	})
+}
+
+// colorKey returns a copy of img with every pixel of color key, ignoring its
+// alpha, made fully transparent.
+func colorKey(img image.Image, key color.NRGBA) *image.NRGBA {
+	b := img.Bounds()
+	dst := image.NewNRGBA(b)
+	draw.Draw(dst, b, img, b.Min, draw.Src)
+	for i := 0; i < len(dst.Pix); i += 4 {
+		p := dst.Pix[i : i+4 : i+4]
+		if p[0] == key.R && p[1] == key.G && p[2] == key.B {
+			p[0], p[1], p[2], p[3] = 0, 0, 0, 0
+		}
+	}
+	return dst
+}
+
+func (r *Renderer) _renderImageLayer(layer *tiled.ImageLayer) error {
+	return r.renderParallax(layer.ParallaxX, layer.ParallaxY, func() error {
+		return r.renderTint(layer.TintColor, func() error {
+			return r.renderBlended(layer.Mode, 1, func() error {
+				return r.drawImageLayer(layer)
+			})
+		})
+	})
+}
+
+// drawImageLayer draws the layer's image at its offset, repeating it across
+// the whole result along the axes it repeats on.
+func (r *Renderer) drawImageLayer(layer *tiled.ImageLayer) error {
+	if layer.Image == nil || layer.Image.Source == "" {
+		return nil
+	}
+
+	key := newSourceImageKey(r.m.GetFileFullPath(layer.Image.Source), layer.Image.Trans)
+	img, err := r.loadSourceImage(key)
+	if err != nil {
+		return err
+	}
+	size := img.Bounds().Size()
+	if size.X == 0 || size.Y == 0 {
+		return nil
+	}
+	if img, err = r.tintedImage(key, img); err != nil {
+		return err
+	}
+
+	// The deprecated position is still honored for maps saved before
+	// offsets were introduced.
+	pos := image.Pt(
+		int(math.Round(layer.OffsetX))+layer.X,
+		int(math.Round(layer.OffsetY))+layer.Y,
+	)
+
+	bounds := r.Result.Bounds()
+	xs, xe := pos.X, pos.X+size.X
+	if layer.RepeatX {
+		xs = bounds.Min.X - mod(bounds.Min.X-pos.X, size.X)
+		xe = bounds.Max.X
+	}
+	ys, ye := pos.Y, pos.Y+size.Y
+	if layer.RepeatY {
+		ys = bounds.Min.Y - mod(bounds.Min.Y-pos.Y, size.Y)
+		ye = bounds.Max.Y
+	}
+
+	var mask image.Image
+	if layer.Opacity < 1 {
+		mask = image.NewUniform(color.Alpha{uint8(layer.Opacity * 255)})
+	}
+	for y := ys; y < ye; y += size.Y {
+		for x := xs; x < xe; x += size.X {
+			rect := image.Rectangle{Min: image.Pt(x, y), Max: image.Pt(x, y).Add(size)}
+			draw.DrawMask(r.Result, rect, img, img.Bounds().Min, mask, image.Point{}, draw.Over)
+		}
+	}
+	return nil
+}
+
+// mod returns a modulo b, which is never negative for positive b.
+func mod(a, b int) int {
+	m := a % b
+	if m < 0 {
+		m += b
+	}
+	return m
+}
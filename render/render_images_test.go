@@ -0,0 +1,112 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"testing"
+	"testing/fstest"
+)
+
+const imageLayerMap = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="4" height="2" tilewidth="8" tileheight="8">
+ %s
+</map>`
+
+func TestRenderer_RenderImageLayer(t *testing.T) {
+	magenta := color.NRGBA{255, 0, 255, 255}
+	transparent := color.NRGBA{}
+	files := fstest.MapFS{
+		// A 4x2 image: red on the left, magenta on the right.
+		"bg.png": {Data: solidTiles(t, 2, red, magenta)},
+	}
+
+	tests := []struct {
+		name   string
+		layer  string
+		render func(r *Renderer) error
+		want   map[[2]int]color.NRGBA
+	}{
+		{
+			name:   "offset",
+			layer:  `<imagelayer id="1" name="bg" offsetx="4" offsety="2"><image source="bg.png"/></imagelayer>`,
+			render: (*Renderer).RenderVisibleImageLayers,
+			want: map[[2]int]color.NRGBA{
+				{3, 2}: transparent, {4, 2}: red, {6, 3}: magenta, {8, 2}: transparent, {4, 4}: transparent,
+			},
+		},
+		{
+			name:   "trans",
+			layer:  `<imagelayer id="1" name="bg"><image source="bg.png" trans="ff00ff"/></imagelayer>`,
+			render: func(r *Renderer) error { return r.RenderImageLayer(0) },
+			want:   map[[2]int]color.NRGBA{{0, 0}: red, {2, 0}: transparent},
+		},
+		{
+			name:   "repeat",
+			layer:  `<imagelayer id="1" name="bg" offsetx="1" repeatx="1" repeaty="1"><image source="bg.png"/></imagelayer>`,
+			render: (*Renderer).RenderVisibleImageLayers,
+			want: map[[2]int]color.NRGBA{
+				{0, 0}: magenta, {1, 0}: red, {3, 0}: magenta, {29, 13}: red, {31, 15}: magenta,
+			},
+		},
+		{
+			name:   "opacity",
+			layer:  `<imagelayer id="1" name="bg" opacity="0.5"><image source="bg.png"/></imagelayer>`,
+			render: (*Renderer).RenderVisibleImageLayers,
+			want:   map[[2]int]color.NRGBA{{0, 0}: {255, 0, 0, 127}},
+		},
+		{
+			name:   "hidden",
+			layer:  `<imagelayer id="1" name="bg" visible="0"><image source="bg.png"/></imagelayer>`,
+			render: (*Renderer).RenderVisibleImageLayers,
+			want:   map[[2]int]color.NRGBA{{0, 0}: transparent},
+		},
+		{
+			name:   "in group",
+			layer:  `<group id="2" name="g"><imagelayer id="1" name="bg" offsetx="8"><image source="bg.png"/></imagelayer></group>`,
+			render: (*Renderer).RenderVisibleGroups,
+			want:   map[[2]int]color.NRGBA{{0, 0}: transparent, {8, 0}: red},
+		},
+		{
+			name:   "full map",
+			layer:  `<imagelayer id="1" name="bg"><image source="bg.png"/></imagelayer>`,
+			render: (*Renderer).RenderVisibleLayersAndObjectGroups,
+			want:   map[[2]int]color.NRGBA{{0, 0}: red},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := loadTestMap(t, fmt.Sprintf(imageLayerMap, tt.layer), files)
+			if err := tt.render(r); err != nil {
+				t.Fatal(err)
+			}
+			for p, want := range tt.want {
+				if c := r.Result.NRGBAAt(p[0], p[1]); c != want {
+					t.Errorf("pixel at %v = %v, want %v", p, c, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// RenderMap clears the result and renders the whole map the way Tiled's
+// "Export as Image" does: the background color first, then every visible
+// layer in the order they appear in the map, with the offsets, opacity, tint
+// colors and visibility of groups applied to the layers in them.
+func (r *Renderer) RenderMap() error {
+	r.Clear()
+	return r.renderMapLayers()
+}
+
+// renderMapLayers fills the result with the map's background color and
+// renders all visible layers onto it.
+func (r *Renderer) renderMapLayers() error {
+	if r.m.BackgroundColor != nil {
+		draw.Draw(r.Result, r.Result.Bounds(), image.NewUniform(r.m.BackgroundColor), image.Point{}, draw.Src)
+	}
+	return r.renderLayerNodes(r.m.OrderedLayers())
+}
+
+// renderLayerNodes renders the visible layers among nodes in order.
+func (r *Renderer) renderLayerNodes(nodes []tiled.LayerNode) error {
+	for _, n := range nodes {
+		if !n.Visible() {
+			continue
+		}
+
+		var err error
+		switch {
+		case n.Layer != nil:
+			err = r._renderLayer(n.Layer)
+		case n.ObjectGroup != nil:
+			err = r._renderObjectGroup(n.ObjectGroup)
+		case n.ImageLayer != nil:
+			err = r._renderImageLayer(n.ImageLayer)
+		case n.Group != nil:
+			err = r._renderGroup(n.Group)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderOffset calls render with everything it draws moved by the given
+// offset, which adds up with the offsets of the groups being rendered.
+func (r *Renderer) renderOffset(offsetX, offsetY float64, render func() error) error {
+	offset := roundOffset(offsetX, offsetY)
+	if offset == (image.Point{}) {
+		return render()
+	}
+
+	result := r.Result
+	r.Result = &image.NRGBA{
+		Pix:    result.Pix,
+		Stride: result.Stride,
+		Rect:   result.Rect.Sub(offset),
+	}
+	defer func() { r.Result = result }()
+
+	return render()
+}
+
+// roundOffset returns the offset layers are moved by, in whole pixels.
+func roundOffset(offsetX, offsetY float64) image.Point {
+	return image.Pt(int(math.Round(offsetX)), int(math.Round(offsetY)))
+}
@@ -0,0 +1,73 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"image/color"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderer_RenderMap(t *testing.T) {
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 4, red, green, blue)}}
+	r := loadTestMap(t, `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="5" height="1" tilewidth="4" tileheight="4" backgroundcolor="#808080">
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="3" columns="3">
+  <image source="tiles.png" width="12" height="4"/>
+ </tileset>
+ <layer id="1" name="a" width="5" height="1"><data encoding="csv">1,1,0,0,0</data></layer>
+ <group id="2" name="outer" offsetx="4">
+  <layer id="3" name="b" width="5" height="1"><data encoding="csv">2,0,0,0,0</data></layer>
+  <group id="4" name="inner" offsetx="4" opacity="0.5">
+   <layer id="5" name="c" width="5" height="1"><data encoding="csv">0,2,0,0,0</data></layer>
+  </group>
+ </group>
+ <layer id="6" name="d" width="5" height="1"><data encoding="csv">0,0,3,0,0</data></layer>
+ <group id="7" name="hidden" visible="0">
+  <layer id="8" name="e" width="5" height="1"><data encoding="csv">1,1,1,1,1</data></layer>
+ </group>
+</map>`, files)
+
+	if err := r.RenderMap(); err != nil {
+		t.Fatal(err)
+	}
+
+	grey := color.NRGBA{128, 128, 128, 255}
+	want := map[int]color.NRGBA{
+		0:  red,
+		4:  green, // the group's offset moves layer b over layer a
+		8:  blue,  // layer d is drawn over the groups before it
+		16: grey,
+	}
+	for x, c := range want {
+		if got := r.Result.NRGBAAt(x, 0); got != c {
+			t.Errorf("pixel %d = %v, want %v", x, got, c)
+		}
+	}
+
+	// Offsets add up in nested groups, and opacity applies to the layers in
+	// the group.
+	if got := r.Result.NRGBAAt(12, 0); got.A != 255 || got.G <= grey.G || got.R >= grey.R {
+		t.Errorf("pixel 12 = %v, want translucent green over grey", got)
+	}
+}
@@ -27,9 +27,9 @@ import (
 	"image/color"
 	"image/draw"
 	"math"
+	"sort"
 
 	"github.com/lafriks/go-tiled"
-	"github.com/lafriks/go-tiled/internal/utils"
 
 	"github.com/disintegration/imaging"
 )
@@ -57,34 +57,33 @@ func (r *Renderer) RenderGroup(groupID int) error {
 	return r._renderGroup(group)
 }
 
+// _renderGroup renders the visible layers of group, blending them with what is
+// below the group as a whole if it has a blend mode or opacity.
 func (r *Renderer) _renderGroup(group *tiled.Group) error {
-	for _, layer := range group.Layers {
-		if !layer.Visible {
-			continue
-		}
-		if err := r._renderLayer(layer); err != nil {
-			return err
-		}
-	}
-
-	for _, objectGroup := range group.ObjectGroups {
-		if !objectGroup.Visible {
-			continue
-		}
-		if err := r._renderObjectGroup(objectGroup); err != nil {
-			return err
-		}
-	}
+	return r.renderParallax(group.ParallaxX, group.ParallaxY, func() error {
+		return r.renderTint(group.TintColor, func() error {
+			return r.renderBlended(group.Mode, group.Opacity, func() error {
+				return r.drawGroup(group)
+			})
+		})
+	})
+}
 
-	return nil
+func (r *Renderer) drawGroup(group *tiled.Group) error {
+	return r.renderOffset(group.OffsetX, group.OffsetY, func() error {
+		return r.renderLayerNodes(group.OrderedLayers())
+	})
 }
 
-// RenderVisibleLayersAndObjectGroups render all layers and object groups, layer first, objectGroup second
-// so the order may be incorrect,
-// you may put them into different groups, then call RenderVisibleGroups
+// RenderVisibleLayersAndObjectGroups render all image layers, layers and object groups, image layers first,
+// layer second, objectGroup third so the order may be incorrect,
+// use RenderMap to render all layers in the order they appear in the map
 func (r *Renderer) RenderVisibleLayersAndObjectGroups() error {
 	// TODO: The order maybe incorrect
 
+	if err := r.RenderVisibleImageLayers(); err != nil {
+		return err
+	}
 	if err := r.RenderVisibleLayers(); err != nil {
 		return err
 	}
@@ -116,28 +115,46 @@ func (r *Renderer) RenderObjectGroup(i int) error {
 
 // positionedObject pairs an object with its already-projected screen position,
 // so sorting and rendering both use one, orientation-correct, source of truth.
+// sortY is the screen y the object is sorted by in topdown object groups.
 type positionedObject struct {
-	obj    *tiled.Object
-	sx, sy float64
+	obj           *tiled.Object
+	sx, sy, sortY float64
 }
 
 func (r *Renderer) _renderObjectGroup(objectGroup *tiled.ObjectGroup) error {
+	return r.renderParallax(objectGroup.ParallaxX, objectGroup.ParallaxY, func() error {
+		return r.renderTint(objectGroup.TintColor, func() error {
+			return r.renderBlended(objectGroup.Mode, 1, func() error {
+				return r.drawObjectGroup(objectGroup)
+			})
+		})
+	})
+}
+
+func (r *Renderer) drawObjectGroup(objectGroup *tiled.ObjectGroup) error {
 	objs := make([]positionedObject, len(objectGroup.Objects))
 	for i, obj := range objectGroup.Objects {
 		sx, sy := r.engine.PixelToScreenCoords(obj.X, obj.Y)
-		objs[i] = positionedObject{obj: obj, sx: sx, sy: sy}
+		sx += objectGroup.OffsetX
+		sy += objectGroup.OffsetY
+		objs[i] = positionedObject{obj: obj, sx: sx, sy: sy, sortY: r.objectSortY(obj, sy)}
 	}
 
-	// sort objects from screen top to screen bottom, so they draw back-to-front;
-	// raw object X/Y aren't screen order for orientations like isometric, where the
-	// grid is sheared, so this must sort by the projected position, not o.X/o.Y.
-	objs = utils.SortAnySlice(objs, func(a, b positionedObject) bool {
-		if a.sy != b.sy {
-			return a.sy < b.sy
-		}
-
-		return a.sx < b.sx
-	})
+	// Objects of index ordered groups are drawn in the order they appear in
+	// the map. Otherwise sort them from screen top to screen bottom, so they
+	// draw back-to-front; raw object X/Y aren't screen order for orientations
+	// like isometric, where the grid is sheared, so this must sort by the
+	// projected position, not o.X/o.Y. The sort is stable, so objects at the
+	// same position keep their order.
+	if objectGroup.DrawOrder != "index" {
+		sort.SliceStable(objs, func(i, j int) bool {
+			a, b := objs[i], objs[j]
+			if a.sortY != b.sortY {
+				return a.sortY < b.sortY
+			}
+			return a.sx < b.sx
+		})
+	}
 
 	for _, obj := range objs {
 		if err := r.renderOneObject(objectGroup, obj.obj, obj.sx, obj.sy); err != nil {
@@ -147,6 +164,24 @@ func (r *Renderer) _renderObjectGroup(objectGroup *tiled.ObjectGroup) error {
 	return nil
 }
 
+// objectSortY returns the screen y of the visual bottom of an object at screen
+// y sy. Tile objects are sorted by the bottom of their image, wherever their
+// anchor is, while other objects are sorted by their position.
+func (r *Renderer) objectSortY(o *tiled.Object, sy float64) float64 {
+	if o.GID == 0 || o.Text != nil {
+		return sy
+	}
+	tile, err := r.m.TileGIDToTile(o.GID)
+	if err != nil {
+		// The error is reported when the object is rendered.
+		return sy
+	}
+
+	size := image.Pt(int(o.Width), int(o.Height))
+	anchor := r.objectAnchor(tile.Tileset, size)
+	return sy - float64(anchor.Y) + float64(size.Y) + float64(tileOffset(tile.Tileset).Y)
+}
+
 // RenderGroupObjectGroup renders single object group in a certain group.
 func (r *Renderer) RenderGroupObjectGroup(groupID, objectGroupID int) error {
 	if groupID >= len(r.m.Groups) {
@@ -168,9 +203,11 @@ func (r *Renderer) renderOneObject(layer *tiled.ObjectGroup, o *tiled.Object, sc
 		return nil
 	}
 
+	if o.Text != nil {
+		return r.renderTextObject(layer, o, screenX, screenY)
+	}
 	if o.GID == 0 {
-		// TODO: o.GID == 0
-		return nil
+		return r.renderShapeObject(layer, o)
 	}
 
 	tile, err := r.m.TileGIDToTile(o.GID)
@@ -182,29 +219,29 @@ func (r *Renderer) renderOneObject(layer *tiled.ObjectGroup, o *tiled.Object, sc
 	if err != nil {
 		return err
 	}
+	if img, err = r.tintedTile(tile, img, false); err != nil {
+		return err
+	}
 
 	bounds := img.Bounds()
 	srcSize := bounds.Size()
 	dstSize := image.Pt(int(o.Width), int(o.Height))
 
 	if !srcSize.Eq(dstSize) {
-		img = imaging.Resize(img, dstSize.X, dstSize.Y, imaging.NearestNeighbor)
+		img = fitImage(img, dstSize, tile.Tileset.FillMode)
 	}
 
-	// TODO: tile.Tileset.ObjectAlignment isn't honored here -- alignment is
-	// always determined purely by the map's orientation (GetObjectAnchor),
-	// ignoring any per-tileset override (e.g. "topleft", "center", ...).
-	anchor := r.engine.GetObjectAnchor(img.Bounds().Size())
+	anchor := r.objectAnchor(tile.Tileset, img.Bounds().Size())
 
 	var originPoint image.Point
 
 	img, originPoint = r._rotateObjectImage(img, o.Rotation, anchor)
 
 	bounds = img.Bounds()
-	pos := bounds.Add(image.Pt(int(screenX), int(screenY)).Sub(originPoint))
+	pos := bounds.Add(image.Pt(int(screenX), int(screenY)).Sub(originPoint)).Add(tileOffset(tile.Tileset))
 
-	if layer.Opacity < 1 {
-		mask := image.NewUniform(color.Alpha{uint8(layer.Opacity * 255)})
+	if opacity := layer.Opacity * o.Opacity; opacity < 1 {
+		mask := image.NewUniform(color.Alpha{uint8(opacity * 255)})
 
 		draw.DrawMask(r.Result, pos, img, img.Bounds().Min, mask, mask.Bounds().Min, draw.Over)
 	} else {
@@ -214,6 +251,20 @@ func (r *Renderer) renderOneObject(layer *tiled.ObjectGroup, o *tiled.Object, sc
 	return nil
 }
 
+// objectAnchor returns the point within a tile object's (unrotated) image,
+// sized imgSize, that aligns to the object's position. Tilesets with an
+// unspecified alignment use the orientation's default anchor.
+func (r *Renderer) objectAnchor(ts *tiled.Tileset, imgSize image.Point) image.Point {
+	if ts.ObjectAlignment == "" || ts.ObjectAlignment == "unspecified" {
+		return r.engine.GetObjectAnchor(imgSize)
+	}
+
+	// As with the engines' anchors, the right and bottom alignments anchor
+	// the image by its outer edges, just past its last column and row.
+	ax, ay := tiled.ObjectAlignmentAnchor(ts.ObjectAlignment, r.m.Orientation)
+	return image.Pt(int(ax*float64(imgSize.X)), int(ay*float64(imgSize.Y)))
+}
+
 // _rotateObjectImage rotates img around anchor (given in img's own, unrotated
 // coordinate space) and returns the rotated image along with anchor's new
 // position within it, so the caller can re-align the rotated image to the
@@ -222,11 +273,12 @@ func (r *Renderer) _rotateObjectImage(img image.Image, rotation float64, anchor
 	bounds := img.Bounds()
 	w := bounds.Dx()
 	h := bounds.Dy()
+	// Anchors are measured to the image's edges, so its corners are too.
 	corners := []image.Point{
 		image.Pt(0, 0),
-		image.Pt(w-1, 0),
-		image.Pt(w-1, h-1),
-		image.Pt(0, h-1),
+		image.Pt(w, 0),
+		image.Pt(w, h),
+		image.Pt(0, h),
 	}
 
 	sin, cos := math.Sincos(math.Pi * rotation / 180)
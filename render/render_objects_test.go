@@ -20,10 +20,13 @@ SOFTWARE.
 package render
 
 import (
+	"fmt"
 	"image"
+	"image/color"
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/lafriks/go-tiled"
 )
@@ -73,10 +76,10 @@ func TestRenderer_rotateObjectImage_anchor(t *testing.T) {
 		anchor   image.Point
 		want     image.Point
 	}{
-		{"no rotation, bottom-left", 0, image.Pt(0, 5), image.Pt(0, 5)},
-		{"no rotation, bottom-center", 0, image.Pt(5, 5), image.Pt(5, 5)},
-		{"90 degrees, bottom-left", 90, image.Pt(0, 5), image.Pt(0, 0)},
-		{"90 degrees, bottom-center", 90, image.Pt(5, 5), image.Pt(0, 5)},
+		{"no rotation, bottom-left", 0, image.Pt(0, 6), image.Pt(0, 6)},
+		{"no rotation, bottom-center", 0, image.Pt(5, 6), image.Pt(5, 6)},
+		{"90 degrees, bottom-left", 90, image.Pt(0, 6), image.Pt(0, 0)},
+		{"90 degrees, bottom-center", 90, image.Pt(5, 6), image.Pt(0, 5)},
 	}
 
 	for _, tt := range tests {
@@ -88,3 +91,80 @@ func TestRenderer_rotateObjectImage_anchor(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderer_ObjectDrawOrder(t *testing.T) {
+	// A tall red object, listed first, and a blue one ending above its
+	// bottom. Top aligned, so their anchors don't match their bottoms.
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 4, red, blue)}}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="2" height="2" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="2" columns="2" objectalignment="top">
+  <image source="tiles.png" width="8" height="4"/>
+ </tileset>
+ <objectgroup id="1" name="objects" draworder="%s">
+  <object id="1" gid="1" x="0" y="0" width="4" height="8"/>
+  <object id="2" gid="2" x="0" y="2" width="4" height="4"/>
+ </objectgroup>
+</map>`
+
+	tests := []struct {
+		drawOrder string
+		want      color.NRGBA
+	}{
+		{"index", blue},
+		{"topdown", red},
+		// Tiled uses topdown when the draw order isn't set.
+		{"", red},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.drawOrder, func(t *testing.T) {
+			r := loadTestMap(t, fmt.Sprintf(tmx, tt.drawOrder), files)
+			if err := r.RenderVisibleObjectGroups(); err != nil {
+				t.Fatal(err)
+			}
+			checkPixels(t, tt.drawOrder, r.Result, map[image.Point]color.NRGBA{
+				{1, 1}: red, {1, 3}: tt.want, {1, 7}: red,
+			})
+		})
+	}
+}
+
+func TestRenderer_ObjectOpacity(t *testing.T) {
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 4, red)}}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="2" height="1" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="1" columns="1">
+  <image source="tiles.png" width="4" height="4"/>
+ </tileset>
+ <group id="1" name="group" opacity="%s">
+  <objectgroup id="2" name="objects" opacity="0.5">
+   <object id="1" gid="1" x="0" y="4" width="4" height="4" opacity="0.5"/>
+   <object id="2" gid="1" x="4" y="4" width="4" height="4"/>
+  </objectgroup>
+ </group>
+</map>`
+
+	tests := []struct {
+		name         string
+		groupOpacity string
+		want         [2]uint8
+	}{
+		{"object and layer", "1", [2]uint8{63, 127}},
+		{"object, layer and group", "0.5", [2]uint8{31, 63}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := loadTestMap(t, fmt.Sprintf(tmx, tt.groupOpacity), files)
+			if err := r.RenderMap(); err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.want {
+				if a := r.Result.NRGBAAt(4*i+1, 1).A; a != want {
+					t.Errorf("alpha of object %d = %d, want %d", i+1, a, want)
+				}
+			}
+		})
+	}
+}
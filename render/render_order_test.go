@@ -0,0 +1,118 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lafriks/go-tiled"
+)
+
+func TestRenderer_forEachCell(t *testing.T) {
+	tests := []struct {
+		renderOrder  string
+		orientation  string
+		staggerIndex tiled.StaggerIndexType
+		want         []image.Point
+	}{
+		{"right-down", "orthogonal", "", []image.Point{{0, 0}, {1, 0}, {2, 0}, {0, 1}, {1, 1}, {2, 1}}},
+		{"right-up", "orthogonal", "", []image.Point{{0, 1}, {1, 1}, {2, 1}, {0, 0}, {1, 0}, {2, 0}}},
+		{"left-down", "isometric", "", []image.Point{{2, 0}, {1, 0}, {0, 0}, {2, 1}, {1, 1}, {0, 1}}},
+		{"left-up", "orthogonal", "", []image.Point{{2, 1}, {1, 1}, {0, 1}, {2, 0}, {1, 0}, {0, 0}}},
+		{"right-down", "staggered", tiled.StaggerIndexOdd, []image.Point{{0, 0}, {2, 0}, {1, 0}, {0, 1}, {2, 1}, {1, 1}}},
+		{"left-down", "hexagonal", tiled.StaggerIndexEven, []image.Point{{1, 0}, {2, 0}, {0, 0}, {1, 1}, {2, 1}, {0, 1}}},
+	}
+
+	for _, tt := range tests {
+		r := &Renderer{m: &tiled.Map{
+			Orientation:  tt.orientation,
+			RenderOrder:  tt.renderOrder,
+			Width:        3,
+			Height:       2,
+			StaggerAxis:  tiled.AxisX,
+			StaggerIndex: tt.staggerIndex,
+		}}
+		var got []image.Point
+		if err := r.forEachCell(func(x, y int) error {
+			got = append(got, image.Pt(x, y))
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %s: got %v, want %v", tt.orientation, tt.renderOrder, got, tt.want)
+		}
+	}
+
+	r := &Renderer{m: &tiled.Map{RenderOrder: "down-right", Width: 1, Height: 1}}
+	if err := r.forEachCell(func(x, y int) error { return nil }); err != ErrUnsupportedRenderOrder {
+		t.Errorf("unknown render order error = %v, want %v", err, ErrUnsupportedRenderOrder)
+	}
+}
+
+func TestRenderer_RenderLayer_RenderOrder(t *testing.T) {
+	// Tiles twice as tall as the grid, overlapping the row above them.
+	tall := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			c := red
+			if x >= 4 {
+				c = green
+			}
+			tall.SetNRGBA(x, y, c)
+		}
+	}
+	files := fstest.MapFS{"tall.png": {Data: encodePNG(t, tall)}}
+
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="%s" width="1" height="2" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="tall" tilewidth="4" tileheight="8" tilecount="2" columns="2">
+  <image source="tall.png" width="8" height="8"/>
+ </tileset>
+ <layer id="1" name="tiles" width="1" height="2"><data encoding="csv">1,2</data></layer>
+</map>`
+
+	tests := []struct {
+		renderOrder string
+		want        color.NRGBA
+	}{
+		{"right-down", green},
+		{"left-down", green},
+		{"right-up", red},
+		{"left-up", red},
+	}
+	for _, tt := range tests {
+		r := loadTestMap(t, fmt.Sprintf(tmx, tt.renderOrder), files)
+		if err := r.RenderVisibleLayers(); err != nil {
+			t.Fatalf("%s: %v", tt.renderOrder, err)
+		}
+		if c := r.Result.NRGBAAt(1, 2); c != tt.want {
+			t.Errorf("%s: overlap = %v, want %v", tt.renderOrder, c, tt.want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/lafriks/go-tiled"
+	"golang.org/x/image/vector"
+)
+
+// DefaultObjectColor is the color shape objects are drawn with when their
+// object group has no color, the same default Tiled uses.
+var DefaultObjectColor = color.NRGBA{R: 0xa0, G: 0xa0, B: 0xa4, A: 0xff}
+
+const (
+	// shapeLineWidth is the unscaled width of shape outlines, in pixels.
+	shapeLineWidth = 2
+	// shapePointRadius is the unscaled radius of point object markers.
+	shapePointRadius = 4
+	// shapeFillAlpha is the alpha closed shapes are filled with, out of 255.
+	shapeFillAlpha = 50
+	// shapeJoinSegments is the number of vertices of the discs that round off
+	// outline joins and ends.
+	shapeJoinSegments = 12
+	// fillStripRows is the number of rows of pixels filled shapes are
+	// rasterized in at a time.
+	fillStripRows = 64
+)
+
+// renderShapeObject draws a rectangle, ellipse, polygon, polyline or point
+// object the way Tiled displays them: outlined in the object group's color
+// and, for closed shapes, filled with a translucent version of it.
+func (r *Renderer) renderShapeObject(layer *tiled.ObjectGroup, o *tiled.Object) error {
+	shape := o.Shape()
+	if shape.Kind == tiled.ShapeText || shape.Kind == tiled.ShapeTile {
+		return nil
+	}
+
+	// Project the outline with its rotation already applied in pixel space,
+	// so that ellipses and rotated shapes end up correctly skewed on
+	// isometric and staggered maps.
+	points := shape.Vertices()
+	for i, p := range points {
+		x, y := r.engine.PixelToScreenCoords(p.X, p.Y)
+		points[i] = tiled.Point{X: x + layer.OffsetX, Y: y + layer.OffsetY}
+	}
+
+	c := DefaultObjectColor
+	if layer.Color != nil {
+		c = color.NRGBAModel.Convert(layer.Color).(color.NRGBA)
+	}
+	opacity := float64(layer.Opacity) * float64(o.Opacity)
+
+	lineWidth := shapeLineWidth * r.objectScale
+	if shape.Kind == tiled.ShapePoint {
+		r.fillPaths([][]tiled.Point{disc(points[0], shapePointRadius*r.objectScale)}, c, opacity)
+		return nil
+	}
+
+	if shape.Closed() && len(points) > 2 {
+		r.fillPaths([][]tiled.Point{points}, c, opacity*shapeFillAlpha/255)
+	}
+	r.fillPaths(strokePaths(points, shape.Closed(), lineWidth), c, opacity)
+	return nil
+}
+
+// fillPaths fills the union of the given closed paths, in screen
+// coordinates, with c at the given opacity.
+func (r *Renderer) fillPaths(paths [][]tiled.Point, c color.NRGBA, opacity float64) {
+	if len(paths) == 0 || opacity <= 0 {
+		return
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, path := range paths {
+		for _, p := range path {
+			minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+			maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+		}
+	}
+	origin := image.Pt(int(math.Floor(minX)), int(math.Floor(minY)))
+	rect := image.Rect(origin.X, origin.Y, int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+	rect = rect.Intersect(r.Result.Bounds())
+	if rect.Empty() {
+		return
+	}
+
+	// Only the visible columns are rasterized, a strip of rows at a time,
+	// however large the paths are. The strips are laid out from the paths'
+	// top edge even when it is outside the result, so that the coverage of
+	// every pixel is the same however much of the paths is drawn, as with
+	// RenderMapParallel. The rasterizer combines overlapping paths by adding
+	// up their signed coverage, so every path is wound the same way for
+	// overlaps to add up rather than cancel each other out.
+	z := vector.NewRasterizer(rect.Dx(), fillStripRows)
+	z.DrawOp = draw.Src
+	mask := image.NewAlpha(image.Rect(0, 0, rect.Dx(), fillStripRows))
+
+	c.A = uint8(math.Round(float64(c.A) * math.Min(opacity, 1)))
+	src := image.NewUniform(c)
+
+	for top := origin.Y + (rect.Min.Y-origin.Y)/fillStripRows*fillStripRows; top < rect.Max.Y; top += fillStripRows {
+		z.Reset(rect.Dx(), fillStripRows)
+		for _, path := range paths {
+			if len(path) < 3 {
+				continue
+			}
+			reverse := len(paths) > 1 && signedArea(path) < 0
+			for i := range path {
+				p := path[i]
+				if reverse {
+					p = path[len(path)-1-i]
+				}
+				x, y := float32(p.X-float64(rect.Min.X)), float32(p.Y-float64(top))
+				if i == 0 {
+					z.MoveTo(x, y)
+				} else {
+					z.LineTo(x, y)
+				}
+			}
+			z.ClosePath()
+		}
+		z.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+
+		strip := image.Rect(rect.Min.X, top, rect.Max.X, top+fillStripRows).Intersect(rect)
+		draw.DrawMask(r.Result, strip, src, image.Point{}, mask, strip.Min.Sub(image.Pt(rect.Min.X, top)), draw.Over)
+	}
+}
+
+// strokePaths returns the closed paths covering the outline through points
+// drawn with the given line width: a quad along every segment and a disc at
+// every vertex, which rounds off joins and ends.
+func strokePaths(points []tiled.Point, closed bool, width float64) [][]tiled.Point {
+	hw := width / 2
+	paths := make([][]tiled.Point, 0, 2*len(points))
+	for i, a := range points {
+		paths = append(paths, disc(a, hw))
+
+		if i == len(points)-1 && !closed {
+			break
+		}
+		b := points[(i+1)%len(points)]
+		dx, dy := b.X-a.X, b.Y-a.Y
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			continue
+		}
+		nx, ny := -dy/l*hw, dx/l*hw
+		paths = append(paths, []tiled.Point{
+			{X: a.X + nx, Y: a.Y + ny},
+			{X: b.X + nx, Y: b.Y + ny},
+			{X: b.X - nx, Y: b.Y - ny},
+			{X: a.X - nx, Y: a.Y - ny},
+		})
+	}
+	return paths
+}
+
+// disc returns a polygon approximating the circle around c with radius r.
+func disc(c tiled.Point, r float64) []tiled.Point {
+	points := make([]tiled.Point, shapeJoinSegments)
+	for i := range points {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / shapeJoinSegments)
+		points[i] = tiled.Point{X: c.X + r*cos, Y: c.Y + r*sin}
+	}
+	return points
+}
+
+// signedArea returns the area enclosed by the polygon, which is negative when
+// it is wound counter-clockwise in screen coordinates.
+func signedArea(points []tiled.Point) float64 {
+	var a float64
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return a / 2
+}
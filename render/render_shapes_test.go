@@ -0,0 +1,167 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"runtime"
+	"testing"
+)
+
+const shapesMap = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="%s" renderorder="right-down" width="4" height="4" tilewidth="%d" tileheight="%d">
+ <objectgroup id="1" name="Shapes" color="#ff0000"%s>
+  <object id="1" x="10" y="10" width="20" height="20"/>
+  <object id="2" x="60" y="60">
+   <point/>
+  </object>
+  <object id="3" x="40" y="10">
+   <polyline points="0,0 30,0"/>
+  </object>
+ </objectgroup>
+</map>`
+
+func TestRenderer_RenderShapeObjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		attrs   string
+		options []RendererOption
+		opaque  []image.Point
+		fill    []image.Point
+		empty   []image.Point
+		alpha   uint8
+	}{
+		{
+			name:   "default",
+			opaque: []image.Point{{10, 20}, {29, 10}, {60, 60}, {55, 10}},
+			fill:   []image.Point{{20, 20}},
+			empty:  []image.Point{{66, 60}, {55, 13}, {55, 30}},
+			alpha:  255,
+		},
+		{
+			name:    "scaled",
+			options: []RendererOption{WithObjectScale(2)},
+			opaque:  []image.Point{{66, 60}, {55, 11}},
+			fill:    []image.Point{{20, 20}},
+			alpha:   255,
+		},
+		{
+			name:   "offset and opacity",
+			attrs:  ` offsetx="5" opacity="0.5"`,
+			opaque: []image.Point{{14, 20}, {65, 60}, {60, 10}},
+			empty:  []image.Point{{10, 20}},
+			alpha:  128,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := loadTestMap(t, fmt.Sprintf(shapesMap, "orthogonal", 20, 20, tt.attrs), nil)
+			for _, opt := range tt.options {
+				opt(r)
+			}
+			if err := r.RenderVisibleObjectGroups(); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, p := range tt.opaque {
+				if a := r.Result.NRGBAAt(p.X, p.Y).A; a != tt.alpha {
+					t.Errorf("alpha at %v = %d, want %d", p, a, tt.alpha)
+				}
+			}
+			for _, p := range tt.fill {
+				if a := r.Result.NRGBAAt(p.X, p.Y).A; a != shapeFillAlpha {
+					t.Errorf("alpha at %v = %d, want %d", p, a, shapeFillAlpha)
+				}
+			}
+			for _, p := range tt.empty {
+				if a := r.Result.NRGBAAt(p.X, p.Y).A; a != 0 {
+					t.Errorf("alpha at %v = %d, want 0", p, a)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderer_RenderShapeObjects_Isometric(t *testing.T) {
+	r := loadTestMap(t, `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="isometric" renderorder="right-down" width="4" height="4" tilewidth="32" tileheight="16">
+ <objectgroup id="1" name="Shapes" color="#ff0000">
+  <object id="1" x="0" y="0" width="16" height="16"/>
+ </objectgroup>
+</map>`, nil)
+	if err := r.RenderVisibleObjectGroups(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rectangle covering the top tile is drawn as a diamond.
+	if a := r.Result.NRGBAAt(64, 8).A; a != shapeFillAlpha {
+		t.Errorf("alpha at center = %d, want %d", a, shapeFillAlpha)
+	}
+	if a := r.Result.NRGBAAt(48, 8).A; a != 255 {
+		t.Errorf("alpha at left corner = %d, want 255", a)
+	}
+	if a := r.Result.NRGBAAt(52, 2).A; a != 0 {
+		t.Errorf("alpha outside the diamond = %d, want 0", a)
+	}
+}
+
+func TestRenderer_LargeShapeAllocations(t *testing.T) {
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="200" height="200" tilewidth="8" tileheight="8">
+ <objectgroup id="1" name="zones">
+  <object id="1" x="0" y="0" width="1600" height="1600"/>
+ </objectgroup>
+</map>`
+	r := loadTestMap(t, tmx, nil)
+
+	allocated := func(render func() error) uint64 {
+		t.Helper()
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		if err := render(); err != nil {
+			t.Fatal(err)
+		}
+		runtime.ReadMemStats(&after)
+		return after.TotalAlloc - before.TotalAlloc
+	}
+
+	// Every band only rasterizes the rows of the shape it draws, rather than
+	// all of them from the shape's top edge down.
+	size := uint64(len(r.Result.Pix))
+	if n := allocated(r.RenderMap); n > 2*size {
+		t.Errorf("RenderMap allocated %d bytes, want at most %d", n, 2*size)
+	}
+	r.Clear()
+	n := allocated(func() error {
+		return r.RenderMapParallel(context.Background(), &ParallelOptions{Bands: 32})
+	})
+	if n > 8*size {
+		t.Errorf("RenderMapParallel allocated %d bytes, want at most %d", n, 8*size)
+	}
+	if got := r.Result.NRGBAAt(800, 800); got.A != shapeFillAlpha {
+		t.Errorf("pixel inside the shape = %v, want alpha %d", got, shapeFillAlpha)
+	}
+}
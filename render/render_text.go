@@ -0,0 +1,256 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lafriks/go-tiled"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// fontKey identifies a cached font face.
+type fontKey struct {
+	family       string
+	size         int
+	bold, italic bool
+}
+
+// fontFace returns the face for text, from the font provider if it has the
+// font family and from the Go fonts otherwise.
+func (r *Renderer) fontFace(text *tiled.Text) (font.Face, error) {
+	key := fontKey{family: text.FontFamily, size: text.Size, bold: text.Bold, italic: text.Italic}
+	if face, ok := r.faces[key]; ok {
+		return face, nil
+	}
+
+	var face font.Face
+	if r.fonts != nil {
+		var err error
+		if face, err = r.fonts.FontFace(key.family, float64(key.size), key.bold, key.italic); err != nil {
+			return nil, err
+		}
+	}
+	if face == nil {
+		var err error
+		if face, err = (GoFontProvider{}).FontFace(key.family, float64(key.size), key.bold, key.italic); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.faces == nil {
+		r.faces = make(map[fontKey]font.Face)
+	}
+	r.faces[key] = face
+	return face, nil
+}
+
+// textLine is a laid out line of a text object.
+type textLine struct {
+	words []string
+	// widths of the words, and of a space between them.
+	widths []fixed.Int26_6
+	space  fixed.Int26_6
+	// justify is set for wrapped lines that are stretched to the full width
+	// of the box when the text is justified.
+	justify bool
+}
+
+func (l textLine) width() fixed.Int26_6 {
+	var w fixed.Int26_6
+	for _, ww := range l.widths {
+		w += ww
+	}
+	if len(l.words) > 1 {
+		w += l.space * fixed.Int26_6(len(l.words)-1)
+	}
+	return w
+}
+
+// measureText returns the advance of s drawn with face.
+func measureText(face font.Face, s string, kerning bool) fixed.Int26_6 {
+	var w fixed.Int26_6
+	prev := rune(-1)
+	for _, c := range s {
+		if kerning && prev >= 0 {
+			w += face.Kern(prev, c)
+		}
+		a, _ := face.GlyphAdvance(c)
+		w += a
+		prev = c
+	}
+	return w
+}
+
+// layoutText splits text into lines, wrapping them at the given width when
+// wrapping is enabled. Words longer than the width are kept whole.
+func layoutText(face font.Face, text *tiled.Text, width fixed.Int26_6) []textLine {
+	space := measureText(face, " ", text.Kerning)
+
+	var lines []textLine
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text.Text, "\r\n", "\n"), "\n") {
+		var words []string
+		if text.Wrap {
+			words = strings.Fields(paragraph)
+		} else {
+			words = []string{paragraph}
+		}
+
+		line := textLine{space: space}
+		for _, word := range words {
+			w := measureText(face, word, text.Kerning)
+			if text.Wrap && len(line.words) > 0 && line.width()+space+w > width {
+				line.justify = true
+				lines = append(lines, line)
+				line = textLine{space: space}
+			}
+			line.words = append(line.words, word)
+			line.widths = append(line.widths, w)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// renderTextObject draws the text of a text object into its box, which is
+// rotated around the object's position and placed at its screen position
+// like a tile object with top-left alignment. Text overflowing the box is
+// clipped, unless the box has no size, in which case it fits the text.
+func (r *Renderer) renderTextObject(layer *tiled.ObjectGroup, o *tiled.Object, screenX, screenY float64) error {
+	if r.textMu != nil {
+		r.textMu.Lock()
+		defer r.textMu.Unlock()
+	}
+
+	text := o.Text
+	face, err := r.fontFace(text)
+	if err != nil {
+		return err
+	}
+
+	metrics := face.Metrics()
+	lines := layoutText(face, text, fixed.Int26_6(o.Width*64))
+	lineHeight := metrics.Height
+	if lineHeight <= 0 {
+		lineHeight = metrics.Ascent + metrics.Descent
+	}
+
+	width, height := int(math.Ceil(o.Width)), int(math.Ceil(o.Height))
+	if width <= 0 {
+		var w fixed.Int26_6
+		for _, l := range lines {
+			w = max(w, l.width())
+		}
+		width = w.Ceil()
+	}
+	if height <= 0 {
+		height = (lineHeight * fixed.Int26_6(len(lines))).Ceil()
+	}
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+
+	c := color.NRGBA{A: 255}
+	if text.Color != nil {
+		c = color.NRGBAModel.Convert(text.Color).(color.NRGBA)
+	}
+	opacity := float64(layer.Opacity) * float64(o.Opacity)
+	c.A = uint8(math.Round(float64(c.A) * math.Min(opacity, 1)))
+	if c.A == 0 {
+		return nil
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	src := image.NewUniform(c)
+	d := &font.Drawer{Dst: img, Src: src, Face: face}
+
+	boxWidth := fixed.I(width)
+	total := lineHeight * fixed.Int26_6(len(lines))
+	var y fixed.Int26_6
+	switch text.VAlign {
+	case "center":
+		y = (fixed.I(height) - total) / 2
+	case "bottom":
+		y = fixed.I(height) - total
+	}
+
+	thickness := max(1, text.Size/16)
+	for _, l := range lines {
+		baseline := y + metrics.Ascent
+		y += lineHeight
+
+		lw := l.width()
+		space := l.space
+		var x fixed.Int26_6
+		switch text.HAlign {
+		case "center":
+			x = (boxWidth - lw) / 2
+		case "right":
+			x = boxWidth - lw
+		case "justify":
+			if l.justify && len(l.words) > 1 && lw < boxWidth {
+				space += (boxWidth - lw) / fixed.Int26_6(len(l.words)-1)
+			}
+		}
+		start := x
+
+		for i, word := range l.words {
+			if i > 0 {
+				x += space
+			}
+			d.Dot = fixed.Point26_6{X: x, Y: baseline}
+			prev := rune(-1)
+			for _, ch := range word {
+				if text.Kerning && prev >= 0 {
+					d.Dot.X += face.Kern(prev, ch)
+				}
+				var buf [utf8.UTFMax]byte
+				d.DrawBytes(buf[:utf8.EncodeRune(buf[:], ch)])
+				prev = ch
+			}
+			x += l.widths[i]
+		}
+
+		decorate := func(y int) {
+			rect := image.Rect(start.Floor(), y, x.Ceil(), y+thickness)
+			draw.Draw(img, rect, src, image.Point{}, draw.Over)
+		}
+		if text.Underline {
+			decorate(baseline.Round() + thickness)
+		}
+		if text.Strikethrough {
+			decorate((baseline - metrics.XHeight/2).Round() - thickness/2)
+		}
+	}
+
+	rotated, origin := r._rotateObjectImage(img, o.Rotation, image.Point{})
+	pos := rotated.Bounds().Add(image.Pt(int(math.Round(screenX)), int(math.Round(screenY))).Sub(origin))
+	draw.Draw(r.Result, pos, rotated, rotated.Bounds().Min, draw.Over)
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"fmt"
+	"image"
+	"testing"
+
+	"golang.org/x/image/font"
+)
+
+// inkBounds returns the bounds of the non-transparent pixels of img.
+func inkBounds(img *image.NRGBA) image.Rectangle {
+	var b image.Rectangle
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if img.NRGBAAt(x, y).A != 0 {
+				b = b.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return b
+}
+
+const textMap = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="10" height="4" tilewidth="20" tileheight="20">
+ <objectgroup id="1" name="Labels">
+  <object id="1" x="20" y="10" width="160" height="60">
+   <text fontfamily="Example Sans" pixelsize="16"%s>%s</text>
+  </object>
+ </objectgroup>
+</map>`
+
+func TestRenderer_RenderTextObject(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs string
+		text  string
+		check func(b image.Rectangle) bool
+	}{
+		{
+			name:  "top left",
+			text:  "Hello",
+			check: func(b image.Rectangle) bool { return b.Min.X < 24 && b.Min.Y < 16 && b.Max.Y < 30 && b.Max.X < 80 },
+		},
+		{
+			name:  "right",
+			attrs: ` halign="right"`,
+			text:  "Hello",
+			check: func(b image.Rectangle) bool { return b.Min.X > 120 && b.Max.X <= 180 },
+		},
+		{
+			name:  "centered",
+			attrs: ` halign="center" valign="center"`,
+			text:  "Hello",
+			check: func(b image.Rectangle) bool {
+				return abs(b.Min.X-20-(180-b.Max.X)) <= 2 && b.Min.Y > 30 && b.Max.Y < 52
+			},
+		},
+		{
+			name:  "bottom",
+			attrs: ` valign="bottom"`,
+			text:  "Hello",
+			check: func(b image.Rectangle) bool { return b.Min.Y > 50 && b.Max.Y <= 70 },
+		},
+		{
+			name:  "wrapped",
+			attrs: ` wrap="1"`,
+			text:  "Hello there, this wraps onto another line",
+			check: func(b image.Rectangle) bool { return b.Max.X <= 180 && b.Max.Y > 30 },
+		},
+		{
+			name:  "not wrapped",
+			text:  "Hello there, this doesn't wrap onto another line",
+			check: func(b image.Rectangle) bool { return b.Max.X == 180 && b.Max.Y < 30 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := loadTestMap(t, fmt.Sprintf(textMap, tt.attrs, tt.text), nil)
+			if err := r.RenderVisibleObjectGroups(); err != nil {
+				t.Fatal(err)
+			}
+			if b := inkBounds(r.Result); b.Empty() || !tt.check(b) {
+				t.Errorf("text drawn at %v", b)
+			}
+		})
+	}
+}
+
+// familyProvider records the families faces are requested for and only
+// provides "Example Sans", using Go Mono.
+type familyProvider struct {
+	requested []string
+}
+
+func (p *familyProvider) FontFace(family string, size float64, bold, italic bool) (font.Face, error) {
+	p.requested = append(p.requested, family)
+	if family != "Example Sans" {
+		return nil, nil
+	}
+	return GoFontProvider{}.FontFace("mono", size, bold, italic)
+}
+
+func TestRenderer_RenderTextObject_FontProvider(t *testing.T) {
+	render := func(t *testing.T, options ...RendererOption) image.Rectangle {
+		r := loadTestMap(t, fmt.Sprintf(textMap, "", "iiiii"), nil)
+		for _, opt := range options {
+			opt(r)
+		}
+		if err := r.RenderVisibleObjectGroups(); err != nil {
+			t.Fatal(err)
+		}
+		return inkBounds(r.Result)
+	}
+
+	fallback := render(t)
+	p := &familyProvider{}
+	mono := render(t, WithFontProvider(p))
+
+	if len(p.requested) != 1 || p.requested[0] != "Example Sans" {
+		t.Errorf("requested families %v, want [Example Sans]", p.requested)
+	}
+	// Narrow letters take up more space in a monospaced font.
+	if mono.Dx() <= fallback.Dx() {
+		t.Errorf("provided font is %dpx wide, fallback %dpx", mono.Dx(), fallback.Dx())
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// ErrInvalidScale represents an error in the scale to render at being negative or not finite.
+var ErrInvalidScale = errors.New("tiled/render: invalid scale")
+
+// renderToChunkPixels is roughly the largest number of pixels of the map
+// RenderTo renders at once.
+var renderToChunkPixels = 1 << 22
+
+// RenderToOptions are the options for rendering a map into an image.
+type RenderToOptions struct {
+	// Offset is the point of the destination image the top-left corner of
+	// the map is drawn at.
+	Offset image.Point
+	// Scale scales the map. Defaults to 1 if zero.
+	Scale float64
+	// Interpolator resamples the map when it is scaled, such as
+	// draw.NearestNeighbor, which keeps pixel art sharp, or draw.CatmullRom
+	// from golang.org/x/image/draw. Defaults to draw.NearestNeighbor.
+	Interpolator xdraw.Interpolator
+	// Op is the operator the map is drawn onto the destination image with.
+	// Defaults to draw.Over.
+	Op draw.Op
+}
+
+// RenderTo renders all visible layers of the map like RenderMap, but draws
+// them into dst instead of the result, which is left as it is. Only the part
+// of the map that ends up within the bounds of dst is rendered, a band at a
+// time, so that scaling a large map down doesn't take rendering the whole of
+// it first.
+func (r *Renderer) RenderTo(dst draw.Image, opts *RenderToOptions) error {
+	var o RenderToOptions
+	if opts != nil {
+		o = *opts
+	}
+	scale := o.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	if !(scale > 0) || math.IsInf(scale, 0) {
+		return ErrInvalidScale
+	}
+	interp := o.Interpolator
+	if interp == nil {
+		interp = xdraw.NearestNeighbor
+	}
+
+	bounds := r.engine.GetFinalImageSize()
+	dr := image.Rect(
+		o.Offset.X, o.Offset.Y,
+		o.Offset.X+int(math.Ceil(float64(bounds.Dx())*scale)),
+		o.Offset.Y+int(math.Ceil(float64(bounds.Dy())*scale)),
+	).Intersect(dst.Bounds())
+	if dr.Empty() {
+		return nil
+	}
+
+	// toMap returns the map coordinate of destination coordinate v along an
+	// axis, rounded down or up.
+	toMap := func(v, offset, origin int, up bool) int {
+		f := float64(v-offset) / scale
+		if up {
+			return origin + int(math.Ceil(f))
+		}
+		return origin + int(math.Floor(f))
+	}
+
+	// The parts of the map are rendered with enough pixels around them for
+	// the interpolator to sample, so that no seams show between them.
+	support := 1.0
+	if k, ok := interp.(*xdraw.Kernel); ok {
+		support = k.Support
+	}
+	margin := int(math.Ceil(support/math.Min(scale, 1))) + 1
+
+	x0 := toMap(dr.Min.X, o.Offset.X, bounds.Min.X, false) - margin
+	x1 := toMap(dr.Max.X, o.Offset.X, bounds.Min.X, true) + margin
+	rows := max(1, int(float64(renderToChunkPixels)/float64(x1-x0)*scale))
+
+	s2d := f64.Aff3{
+		scale, 0, float64(o.Offset.X) - float64(bounds.Min.X)*scale,
+		0, scale, float64(o.Offset.Y) - float64(bounds.Min.Y)*scale,
+	}
+	for y := dr.Min.Y; y < dr.Max.Y; y += rows {
+		band := image.Rect(dr.Min.X, y, dr.Max.X, min(y+rows, dr.Max.Y))
+		src := image.Rect(
+			x0, toMap(band.Min.Y, o.Offset.Y, bounds.Min.Y, false)-margin,
+			x1, toMap(band.Max.Y, o.Offset.Y, bounds.Min.Y, true)+margin,
+		).Intersect(bounds)
+
+		part := r.partRenderer(nil, image.NewNRGBA(src))
+		if err := part.renderMapLayers(); err != nil {
+			return err
+		}
+
+		if scale == 1 {
+			sp := band.Min.Sub(o.Offset).Add(bounds.Min)
+			draw.Draw(dst, band, part.Result, sp, o.Op)
+			continue
+		}
+		interp.Transform(subImage(dst, band), s2d, part.Result, src, o.Op, nil)
+	}
+	return nil
+}
+
+// subImage returns a view of the part of img within rect, which shares the
+// pixels of img.
+func subImage(img draw.Image, rect image.Rectangle) draw.Image {
+	if s, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		if sub, ok := s.SubImage(rect).(draw.Image); ok {
+			return sub
+		}
+	}
+	return clippedImage{img, rect.Intersect(img.Bounds())}
+}
+
+// clippedImage is a view of the part of an image within rect, for images
+// without a SubImage method.
+type clippedImage struct {
+	draw.Image
+	rect image.Rectangle
+}
+
+// Bounds implements image.Image.
+func (c clippedImage) Bounds() image.Rectangle {
+	return c.rect
+}
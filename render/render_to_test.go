@@ -0,0 +1,120 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+func TestRenderer_RenderTo(t *testing.T) {
+	r := loadParallelMap(t, "orthogonal")
+	if err := r.RenderMap(); err != nil {
+		t.Fatal(err)
+	}
+	full := r.Result
+	w, h := full.Bounds().Dx(), full.Bounds().Dy()
+
+	// Small chunks, so that every map is rendered in several parts.
+	defer func(n int) { renderToChunkPixels = n }(renderToChunkPixels)
+	renderToChunkPixels = 200
+
+	t.Run("offset", func(t *testing.T) {
+		dst := image.NewRGBA(image.Rect(0, 0, w+20, h+10))
+		if err := r.RenderTo(dst, &RenderToOptions{Offset: image.Pt(20, 10)}); err != nil {
+			t.Fatal(err)
+		}
+		if r.Result != full {
+			t.Error("RenderTo changed the result")
+		}
+
+		want := image.NewRGBA(dst.Bounds())
+		draw.Draw(want, full.Bounds().Add(image.Pt(20, 10)), full, image.Point{}, draw.Over)
+		compareImages(t, dst, want, 0)
+	})
+
+	t.Run("nearest neighbor", func(t *testing.T) {
+		// A destination smaller than the scaled map clips it.
+		dst := image.NewNRGBA(image.Rect(0, 0, 2*w-7, 2*h-5))
+		if err := r.RenderTo(dst, &RenderToOptions{Scale: 2}); err != nil {
+			t.Fatal(err)
+		}
+
+		want := image.NewNRGBA(dst.Bounds())
+		for y := 0; y < want.Bounds().Dy(); y++ {
+			for x := 0; x < want.Bounds().Dx(); x++ {
+				want.SetNRGBA(x, y, full.NRGBAAt(x/2, y/2))
+			}
+		}
+		compareImages(t, dst, want, 0)
+	})
+
+	t.Run("catmull-rom", func(t *testing.T) {
+		dst := image.NewRGBA(image.Rect(0, 0, w/3, h/3))
+		if err := r.RenderTo(dst, &RenderToOptions{Scale: 1.0 / 3, Interpolator: xdraw.CatmullRom}); err != nil {
+			t.Fatal(err)
+		}
+
+		want := image.NewRGBA(dst.Bounds())
+		xdraw.CatmullRom.Scale(want, want.Bounds(), full, full.Bounds(), draw.Over, nil)
+		compareImages(t, dst, want, 1)
+	})
+
+	t.Run("invalid scale", func(t *testing.T) {
+		if err := r.RenderTo(image.NewRGBA(image.Rect(0, 0, 1, 1)), &RenderToOptions{Scale: -1}); err != ErrInvalidScale {
+			t.Errorf("RenderTo() = %v, want %v", err, ErrInvalidScale)
+		}
+	})
+}
+
+// compareImages reports the pixels of got that differ from those of want by
+// more than tolerance in any channel.
+func compareImages(t *testing.T, got, want image.Image, tolerance int) {
+	t.Helper()
+
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("bounds = %v, want %v", got.Bounds(), want.Bounds())
+	}
+	diffs := 0
+	b := got.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			g := color.RGBA64Model.Convert(got.At(x, y)).(color.RGBA64)
+			w := color.RGBA64Model.Convert(want.At(x, y)).(color.RGBA64)
+			d := max(abs(int(g.R>>8)-int(w.R>>8)), abs(int(g.G>>8)-int(w.G>>8)), abs(int(g.B>>8)-int(w.B>>8)), abs(int(g.A>>8)-int(w.A>>8)))
+			if d > tolerance {
+				if diffs < 5 {
+					t.Errorf("pixel at (%d, %d) = %v, want %v", x, y, g, w)
+				}
+				diffs++
+			}
+		}
+	}
+	if diffs > 0 {
+		t.Errorf("%d pixels differ", diffs)
+	}
+}
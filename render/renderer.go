@@ -23,6 +23,7 @@ SOFTWARE.
 package render
 
 import (
+	"context"
 	"errors"
 	"image"
 	"image/color"
@@ -32,16 +33,21 @@ import (
 	"image/png"
 	"io"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/disintegration/imaging"
 	"github.com/lafriks/go-tiled"
+	"golang.org/x/image/font"
 )
 
 var (
 	// ErrUnsupportedOrientation represents an error in the unsupported orientation for rendering.
-	ErrUnsupportedOrientation = errors.New("tiled/render: unsupported orientation")
+	// It's the same error as tiled.ErrUnsupportedOrientation.
+	ErrUnsupportedOrientation = tiled.ErrUnsupportedOrientation
 	// ErrUnsupportedRenderOrder represents an error in the unsupported order for rendering.
 	ErrUnsupportedRenderOrder = errors.New("tiled/render: unsupported render order")
 
@@ -66,8 +72,10 @@ type RendererEngine interface {
 	// screen space, so orientations that reshape the grid (e.g. isometric) must project
 	// them the same way tile positions are projected.
 	PixelToScreenCoords(x, y float64) (float64, float64)
-	// GetObjectAnchor returns the point within a tile object's (unrotated) image,
+	// GetObjectAnchor returns the point of a tile object's (unrotated) image,
 	// sized imgSize, that should align to the object's projected screen position.
+	// Like Object.Shape, it's measured to the image's edges, so that the
+	// bottom is at imgSize.Y rather than on the last row of pixels.
 	// Per the Tiled spec this is orientation-dependent: bottom-left for orthogonal
 	// maps, bottom-center for isometric maps.
 	GetObjectAnchor(imgSize image.Point) image.Point
@@ -75,21 +83,60 @@ type RendererEngine interface {
 
 // Renderer represents an rendering engine.
 type Renderer struct {
-	m         *tiled.Map
-	Result    *image.NRGBA // The image result after rendering using the Render functions.
-	tileCache map[uint32]image.Image
-	engine    RendererEngine
-	fs        fs.FS
+	m      *tiled.Map
+	Result *image.NRGBA // The image result after rendering using the Render functions.
+	engine RendererEngine
+	fs     fs.FS
+	// cache holds the decoded images and the tile images cut from them, and
+	// tileKeys the keys of the tiles' images in it, by GID.
+	cache    *TileImageCache
+	tileKeys map[uint32]tileImageKey
+	// time is the animation time tiles are rendered at.
+	time time.Duration
+	// animations holds the animated tiles of each tileset, by tile ID.
+	animations map[*tiled.Tileset]map[uint32]*tiled.TilesetTile
+	// objectScale scales the lines and markers of shape objects.
+	objectScale float64
+	// fonts provides the faces of text objects, which are cached in faces.
+	// Faces aren't safe for concurrent use, so renderers of parts of the map
+	// share textMu to draw text one at a time.
+	fonts  FontProvider
+	faces  map[fontKey]font.Face
+	textMu *sync.Mutex
+	// camera is the view being rendered by RenderCamera, and parallax and
+	// shift are the compound parallax factor and offset of the layer being
+	// rendered through it.
+	camera   *Camera
+	parallax [2]float64
+	shift    image.Point
+	// tint is the compound tint color of the layer being rendered, if any.
+	tint *color.NRGBA
+	// decoders holds the image decoders set up with WithImageDecoder, by
+	// file extension.
+	decoders map[string]ImageDecoder
+	// cull is set when rendering a part of the map, to skip the tiles that
+	// can't overlap it, and margin is how far outside of their grid cell tiles
+	// may be drawn, once known.
+	cull   bool
+	margin int
+	// ctx stops rendering a band of RenderMapParallel once done.
+	ctx context.Context
 }
 
 // NewRenderer creates new rendering engine instance.
-func NewRenderer(m *tiled.Map) (*Renderer, error) {
-	return NewRendererWithFileSystem(m, nil)
+func NewRenderer(m *tiled.Map, options ...RendererOption) (*Renderer, error) {
+	return NewRendererWithFileSystem(m, nil, options...)
 }
 
 // NewRendererWithFileSystem creates new rendering engine instance with a custom file system.
-func NewRendererWithFileSystem(m *tiled.Map, fs fs.FS) (*Renderer, error) {
-	r := &Renderer{m: m, tileCache: make(map[uint32]image.Image), fs: fs}
+func NewRendererWithFileSystem(m *tiled.Map, fs fs.FS, options ...RendererOption) (*Renderer, error) {
+	r := &Renderer{m: m, fs: fs, objectScale: 1, parallax: [2]float64{1, 1}, textMu: new(sync.Mutex)}
+	for _, opt := range options {
+		opt(r)
+	}
+	if r.cache == nil {
+		r.cache = NewTileImageCache(0)
+	}
 	switch r.m.Orientation {
 	case "orthogonal":
 		r.engine = &OrthogonalRendererEngine{}
@@ -116,107 +163,303 @@ func (r *Renderer) open(f string) (io.ReadCloser, error) {
 	return r.fs.Open(filepath.ToSlash(f))
 }
 
-// TODO: tile.Tileset.TileRenderSize/FillMode aren't honored -- tiles are
-// always drawn at their native cropped/source size ("tile" render size
-// behavior). A tileset with TileRenderSize "grid" should instead have its
-// tiles scaled to the map's grid cell size, using FillMode ("stretch" or
-// "preserve-aspect-fit") to decide how; that resizing isn't implemented.
+// getTileImageKey returns the key of the image of tile, or of its current
+// frame if it's animated, in the tile image cache.
+func (r *Renderer) getTileImageKey(tile *tiled.LayerTile) (tileImageKey, error) {
+	frame := r.animationFrame(tile)
+	gid := frame.Tileset.FirstGID + frame.ID
+	key, ok := r.tileKeys[gid]
+	if !ok {
+		src, rect, err := frame.Tileset.TileImageSource(frame.ID)
+		if err != nil {
+			return tileImageKey{}, err
+		}
+		key = tileImageKey{
+			source:      newSourceImageKey(frame.Tileset.GetFileFullPath(src.Source), src.Trans),
+			rect:        rect,
+			orientation: r.m.Orientation,
+		}
+		if r.tileKeys == nil {
+			r.tileKeys = make(map[uint32]tileImageKey)
+		}
+		r.tileKeys[gid] = key
+	}
+	key.d, key.h, key.v, key.rotated120 = tile.DiagonalFlip, tile.HorizontalFlip, tile.VerticalFlip, tile.RotatedHexagonal120
+	return key, nil
+}
+
 func (r *Renderer) getTileImage(tile *tiled.LayerTile) (image.Image, error) {
-	timg, ok := r.tileCache[tile.Tileset.FirstGID+tile.ID]
-	if ok {
-		return r.engine.RotateTileImage(tile, timg), nil
+	key, err := r.getTileImageKey(tile)
+	if err != nil {
+		return nil, err
 	}
-	// Precache all tiles in tileset
-	if tile.Tileset.Image == nil {
-		for i := 0; i < len(tile.Tileset.Tiles); i++ {
-			if tile.Tileset.Tiles[i].ID == tile.ID {
-				sf, err := r.open(tile.Tileset.GetFileFullPath(tile.Tileset.Tiles[i].Image.Source))
-				if err != nil {
-					return nil, err
-				}
-				defer sf.Close()
-				timg, _, err = image.Decode(sf)
-				if err != nil {
-					return nil, err
-				}
-				r.tileCache[tile.Tileset.FirstGID+tile.ID] = timg
-			}
-		}
-	} else {
-		sf, err := r.open(tile.Tileset.GetFileFullPath(tile.Tileset.Image.Source))
+	frame := r.animationFrame(tile)
+
+	return r.cache.load(key, func() (image.Image, error) {
+		// Every tile cut from the same image shares its decoded copy.
+		img, err := r.loadSourceImage(key.source)
 		if err != nil {
 			return nil, err
 		}
-		defer sf.Close()
 
-		img, _, err := image.Decode(sf)
+		rect := key.rect
+		bounds := img.Bounds()
+		if rect.Dx() == 0 {
+			rect.Max.X = bounds.Max.X
+		}
+		if rect.Dy() == 0 {
+			rect.Max.Y = bounds.Max.Y
+		}
+		if !rect.Eq(bounds) {
+			img = imaging.Crop(img, rect)
+		}
+		return r.engine.RotateTileImage(frame, img), nil
+	})
+}
+
+// fittedTileKey returns the key of the image of tile, whose own image has
+// key, fitted into a grid cell.
+func (r *Renderer) fittedTileKey(tile *tiled.LayerTile, key tileImageKey) fittedTileKey {
+	return fittedTileKey{
+		tile:     key,
+		size:     image.Pt(r.m.TileWidth, r.m.TileHeight),
+		fillMode: tile.Tileset.FillMode,
+	}
+}
+
+// getLayerTileImage returns the image to draw for tile on a tile layer, which
+// is fitted into a grid cell according to the tileset's fill mode if the
+// tileset is rendered at the grid size.
+func (r *Renderer) getLayerTileImage(tile *tiled.LayerTile) (image.Image, error) {
+	if tile.Tileset.TileRenderSize != "grid" {
+		return r.getTileImage(tile)
+	}
+
+	tileKey, err := r.getTileImageKey(tile)
+	if err != nil {
+		return nil, err
+	}
+	key := r.fittedTileKey(tile, tileKey)
+	if img, ok := r.cache.cached(key); ok {
+		return img, nil
+	}
+	return r.cache.load(key, func() (image.Image, error) {
+		img, err := r.getTileImage(tile)
 		if err != nil {
 			return nil, err
 		}
+		return fitImage(img, key.size, key.fillMode), nil
+	})
+}
 
-		for i := uint32(0); i < uint32(tile.Tileset.TileCount); i++ {
-			rect := tile.Tileset.GetTileRect(i)
-			r.tileCache[i+tile.Tileset.FirstGID] = imaging.Crop(img, rect)
-			if tile.ID == i {
-				timg = r.tileCache[i+tile.Tileset.FirstGID]
-			}
-		}
+// fitImage scales img to the given size. With the "preserve-aspect-fit" fill
+// mode it is scaled uniformly to fit and centered on a transparent image of
+// that size, otherwise it is stretched.
+func fitImage(img image.Image, size image.Point, fillMode string) image.Image {
+	src := img.Bounds().Size()
+	if src.Eq(size) || src.X == 0 || src.Y == 0 || size.X <= 0 || size.Y <= 0 {
+		return img
+	}
+	if fillMode != "preserve-aspect-fit" {
+		return imaging.Resize(img, size.X, size.Y, imaging.NearestNeighbor)
 	}
 
-	return r.engine.RotateTileImage(tile, timg), nil
+	scale := math.Min(float64(size.X)/float64(src.X), float64(size.Y)/float64(src.Y))
+	w := max(1, int(math.Round(float64(src.X)*scale)))
+	h := max(1, int(math.Round(float64(src.Y)*scale)))
+	scaled := imaging.Resize(img, w, h, imaging.NearestNeighbor)
+
+	dst := image.NewNRGBA(image.Rectangle{Max: size})
+	pos := image.Pt((size.X-w)/2, (size.Y-h)/2)
+	draw.Draw(dst, scaled.Bounds().Add(pos), scaled, image.Point{}, draw.Src)
+	return dst
+}
+
+// tileOffset returns the offset tiles of ts are drawn at.
+func tileOffset(ts *tiled.Tileset) image.Point {
+	if ts == nil || ts.TileOffset == nil {
+		return image.Point{}
+	}
+	return image.Pt(ts.TileOffset.X, ts.TileOffset.Y)
 }
 
 func (r *Renderer) _renderLayer(layer *tiled.Layer) error {
-	// TODO: layer.ParallaxX/Y and Map.ParallaxOriginX/Y aren't applied here --
-	// every layer is drawn at the same 1:1 position regardless of its parallax
-	// factor. This renderer only produces a single static, full-map image with
-	// no notion of a scrolling viewport, so there's currently nothing for a
-	// parallax offset to be relative to; supporting it would need a
-	// viewport/camera position to be introduced first.
-	var xs, xe, xi, ys, ye, yi int
-	if r.m.RenderOrder == "" || r.m.RenderOrder == "right-down" {
-		xs = 0
-		xe = r.m.Width
-		xi = 1
-		ys = 0
-		ye = r.m.Height
-		yi = 1
-	} else {
+	return r.renderParallax(layer.ParallaxX, layer.ParallaxY, func() error {
+		return r.renderTint(layer.TintColor, func() error {
+			return r.renderBlended(layer.Mode, 1, func() error {
+				return r.renderOffset(layer.OffsetX, layer.OffsetY, func() error {
+					return r.drawLayer(layer)
+				})
+			})
+		})
+	})
+}
+
+// drawLayer draws the tiles of layer onto the result.
+func (r *Renderer) drawLayer(layer *tiled.Layer) error {
+	return r.forEachCell(func(x, y int) error {
+		tile := layer.Tiles[y*r.m.Width+x]
+		if tile.IsNil() || !r.tileMayBeVisible(tile, x, y) {
+			return nil
+		}
+
+		img, err := r.getLayerTileImage(tile)
+		if err != nil {
+			return err
+		}
+		if img, err = r.tintedTile(tile, img, tile.Tileset.TileRenderSize == "grid"); err != nil {
+			return err
+		}
+
+		pos := r.engine.GetTilePosition(x, y, img.Bounds().Size()).Add(tileOffset(tile.Tileset))
+		rect := image.Rectangle{Min: pos, Max: pos.Add(img.Bounds().Size())}
+
+		if layer.Opacity < 1 {
+			mask := image.NewUniform(color.Alpha{uint8(layer.Opacity * 255)})
+
+			draw.DrawMask(r.Result, rect, img, img.Bounds().Min, mask, mask.Bounds().Min, draw.Over)
+		} else {
+			draw.Draw(r.Result, rect, img, img.Bounds().Min, draw.Over)
+		}
+		return nil
+	})
+}
+
+// forEachCell calls fn for every cell of the map, in the order tiles are drawn
+// in for the map's render order, so that tiles taller or wider than the grid
+// overlap their neighbours the way they do in Tiled.
+func (r *Renderer) forEachCell(fn func(x, y int) error) error {
+	var leftToRight, topToBottom bool
+	switch r.m.RenderOrder {
+	case "", "right-down":
+		leftToRight, topToBottom = true, true
+	case "right-up":
+		leftToRight = true
+	case "left-down":
+		topToBottom = true
+	case "left-up":
+	default:
 		return ErrUnsupportedRenderOrder
 	}
 
-	i := 0
-	for y := ys; y*yi < ye; y = y + yi {
-		for x := xs; x*xi < xe; x = x + xi {
-			if layer.Tiles[i].IsNil() {
-				i++
-				continue
-			}
+	// On maps staggered along the x axis every other column is shifted down
+	// by half a tile, so each row is drawn in two passes, with the columns
+	// that are shifted down last.
+	parities := []int{-1}
+	if (r.m.Orientation == "staggered" || r.m.Orientation == "hexagonal") && r.m.StaggerAxis == tiled.AxisX {
+		shifted := 1
+		if r.m.StaggerIndex == tiled.StaggerIndexEven {
+			shifted = 0
+		}
+		parities = []int{1 - shifted, shifted}
+	}
 
-			img, err := r.getTileImage(layer.Tiles[i])
-			if err != nil {
+	for j := 0; j < r.m.Height; j++ {
+		y := j
+		if !topToBottom {
+			y = r.m.Height - 1 - j
+		}
+		if r.ctx != nil {
+			if err := r.ctx.Err(); err != nil {
 				return err
 			}
+		}
+		x0, x1 := r.visibleColumns(y)
+		for _, parity := range parities {
+			for i := x0; i <= x1; i++ {
+				x := i
+				if !leftToRight {
+					x = x0 + x1 - i
+				}
+				if parity >= 0 && x%2 != parity {
+					continue
+				}
+				if err := fn(x, y); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
 
-			pos := r.engine.GetTilePosition(x, y, img.Bounds().Size())
-			rect := image.Rectangle{Min: pos, Max: pos.Add(img.Bounds().Size())}
+// tileMayBeVisible reports whether the tile in grid cell (x, y) may overlap
+// the result, judging by the size and offset of the largest tiles with a
+// generous margin, so that rendering a small part of a large map skips most
+// tile images.
+func (r *Renderer) tileMayBeVisible(tile *tiled.LayerTile, x, y int) bool {
+	if !r.cull || tile.Tileset == nil {
+		return true
+	}
+	m := r.cullMargin()
+	pos := r.engine.GetTilePosition(x, y, image.Pt(m, m))
+	return image.Rect(pos.X-m, pos.Y-m, pos.X+2*m, pos.Y+2*m).Overlaps(r.Result.Bounds())
+}
 
-			// TODO: layer.Mode (compositing/blend mode, e.g. "multiply",
-			// "screen", ...) isn't honored -- every layer is composited with
-			// plain draw.Over ("normal" mode), regardless of what Mode is set to.
-			if layer.Opacity < 1 {
-				mask := image.NewUniform(color.Alpha{uint8(layer.Opacity * 255)})
+// visibleColumns returns the range of columns of row y with tiles that may
+// overlap the result, which is empty if there are none. The cells of a row
+// are projected along a line, give or take the half a tile staggered columns
+// are shifted by, which the margin covers.
+func (r *Renderer) visibleColumns(y int) (int, int) {
+	if !r.cull || r.m.Width < 2 {
+		return 0, r.m.Width - 1
+	}
 
-				draw.DrawMask(r.Result, rect, img, img.Bounds().Min, mask, mask.Bounds().Min, draw.Over)
-			} else {
-				draw.Draw(r.Result, rect, img, img.Bounds().Min, draw.Over)
+	m := r.cullMargin()
+	sq := image.Pt(m, m)
+	p0 := r.engine.GetTilePosition(0, y, sq)
+	step := 1
+	if r.m.Width > 2 {
+		step = 2
+	}
+	p1 := r.engine.GetTilePosition(step, y, sq)
+
+	// Column x may overlap if min < p + x*d + 2m and p + x*d - m < max along
+	// both axes, with p the position of the first column and d the distance
+	// between columns.
+	lo, hi := 0.0, float64(r.m.Width-1)
+	clip := func(p, d float64, min, max int) {
+		lower, upper := float64(min)-p-float64(2*m), float64(max)-p+float64(m)
+		if d == 0 {
+			if lower >= 0 || upper <= 0 {
+				lo, hi = 1, 0
 			}
+			return
+		}
+		a, b := lower/d, upper/d
+		if d < 0 {
+			a, b = b, a
+		}
+		lo, hi = math.Max(lo, math.Floor(a)-1), math.Min(hi, math.Ceil(b)+1)
+	}
+	b := r.Result.Bounds()
+	clip(float64(p0.X), float64(p1.X-p0.X)/float64(step), b.Min.X, b.Max.X)
+	clip(float64(p0.Y), float64(p1.Y-p0.Y)/float64(step), b.Min.Y, b.Max.Y)
+	if lo > hi {
+		return 0, -1
+	}
+	return int(lo), int(hi)
+}
 
-			i++
+// cullMargin returns how far outside of their grid cell tiles may be drawn,
+// judging by the largest tiles and offsets of the map's tilesets.
+func (r *Renderer) cullMargin() int {
+	if r.margin == 0 {
+		r.margin = max(r.m.TileWidth, r.m.TileHeight, 1)
+		for _, ts := range r.m.Tilesets {
+			off := tileOffset(ts)
+			r.margin = max(r.margin, ts.TileWidth+abs(off.X), ts.TileHeight+abs(off.Y))
 		}
 	}
+	return r.margin
+}
 
-	return nil
+// abs returns the absolute value of v.
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
 }
 
 // RenderGroupLayer renders single map layer in a certain group.
@@ -255,6 +498,44 @@ func (r *Renderer) RenderVisibleLayers() error {
 	return nil
 }
 
+// SetTime sets the time since the start of the map's tile animations that
+// following renders show, so that animated tiles are drawn with the frame
+// shown at that time instead of their first frame.
+func (r *Renderer) SetTime(t time.Duration) {
+	r.time = t
+}
+
+// Time returns the animation time tiles are rendered at.
+func (r *Renderer) Time() time.Duration {
+	return r.time
+}
+
+// animationFrame returns the tile to draw in place of tile at the current
+// animation time, with the same flips.
+func (r *Renderer) animationFrame(tile *tiled.LayerTile) *tiled.LayerTile {
+	animated, ok := r.animations[tile.Tileset]
+	if !ok {
+		animated = make(map[uint32]*tiled.TilesetTile)
+		for _, t := range tile.Tileset.Tiles {
+			if t.IsAnimated() {
+				animated[t.ID] = t
+			}
+		}
+		if r.animations == nil {
+			r.animations = make(map[*tiled.Tileset]map[uint32]*tiled.TilesetTile)
+		}
+		r.animations[tile.Tileset] = animated
+	}
+
+	t, ok := animated[tile.ID]
+	if !ok {
+		return tile
+	}
+	frame := *tile
+	frame.ID = t.FrameAt(r.time)
+	return &frame
+}
+
 // Clear clears the render result to allow for separation of layers. For example, you can
 // render a layer, make a copy of the render, clear the renderer, and repeat for each
 // layer in the Map.
@@ -0,0 +1,306 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lafriks/go-tiled"
+)
+
+func checkPixels(t *testing.T, name string, img *image.NRGBA, want map[image.Point]color.NRGBA) {
+	t.Helper()
+	for p, c := range want {
+		if got := img.NRGBAAt(p.X, p.Y); got != c {
+			t.Errorf("%s: pixel at %v = %v, want %v", name, p, got, c)
+		}
+	}
+}
+
+func TestRenderer_TileOffset(t *testing.T) {
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 4, red)}}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="2" height="2" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="1" columns="1" objectalignment="bottomleft">
+  <tileoffset x="1" y="-1"/>
+  <image source="tiles.png" width="4" height="4"/>
+ </tileset>
+ <layer id="1" name="tiles" width="2" height="2"><data encoding="csv">1,0,0,0</data></layer>
+ <objectgroup id="2" name="objects">
+  <object id="1" gid="1" x="4" y="8" width="4" height="4"/>
+ </objectgroup>
+</map>`
+
+	r := loadTestMap(t, tmx, files)
+	if err := r.RenderVisibleLayers(); err != nil {
+		t.Fatal(err)
+	}
+	checkPixels(t, "layer", r.Result, map[image.Point]color.NRGBA{
+		{0, 0}: {}, {1, 0}: red, {4, 2}: red, {4, 3}: {},
+	})
+
+	r.Clear()
+	if err := r.RenderVisibleObjectGroups(); err != nil {
+		t.Fatal(err)
+	}
+	// The object covers (4, 4)-(7, 7) before being moved by the offset.
+	checkPixels(t, "object", r.Result, map[image.Point]color.NRGBA{
+		{4, 4}: {}, {5, 3}: red, {7, 6}: red, {5, 2}: {}, {7, 7}: {},
+	})
+}
+
+func TestRenderer_TileRenderSize(t *testing.T) {
+	// One 8x4 tile, rendered into 4x4 grid cells.
+	files := fstest.MapFS{"wide.png": {Data: solidTiles(t, 4, red, red)}}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="2" height="1" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="wide" tilewidth="8" tileheight="4" tilecount="1" columns="1" %s>
+  <image source="wide.png" width="8" height="4"/>
+ </tileset>
+ <layer id="1" name="tiles" width="2" height="1"><data encoding="csv">1,0</data></layer>
+</map>`
+
+	tests := []struct {
+		attrs string
+		want  map[image.Point]color.NRGBA
+	}{
+		{`tilerendersize="tile"`, map[image.Point]color.NRGBA{{0, 0}: red, {7, 3}: red}},
+		{`tilerendersize="grid"`, map[image.Point]color.NRGBA{{0, 0}: red, {3, 3}: red, {4, 0}: {}}},
+		{`tilerendersize="grid" fillmode="preserve-aspect-fit"`, map[image.Point]color.NRGBA{
+			{0, 0}: {}, {0, 1}: red, {3, 2}: red, {3, 3}: {}, {4, 1}: {},
+		}},
+	}
+	for _, tt := range tests {
+		r := loadTestMap(t, fmt.Sprintf(tmx, tt.attrs), files)
+		if err := r.RenderVisibleLayers(); err != nil {
+			t.Fatal(err)
+		}
+		checkPixels(t, tt.attrs, r.Result, tt.want)
+	}
+}
+
+func TestRenderer_ObjectAlignment(t *testing.T) {
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 4, red)}}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="%s" renderorder="right-down" width="2" height="2" tilewidth="%d" tileheight="%d">
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="1" columns="1" objectalignment="%s">
+  <image source="tiles.png" width="4" height="4"/>
+ </tileset>
+ <objectgroup id="1" name="objects">
+  <object id="1" gid="1" x="%d" y="%d" width="4" height="4"/>
+ </objectgroup>
+</map>`
+
+	tests := []struct {
+		orientation string
+		alignment   string
+		x, y        int
+		want        map[image.Point]color.NRGBA
+	}{
+		{"orthogonal", "topleft", 0, 0, map[image.Point]color.NRGBA{{0, 0}: red, {3, 3}: red, {4, 4}: {}}},
+		{"orthogonal", "center", 4, 4, map[image.Point]color.NRGBA{{1, 1}: {}, {2, 2}: red, {5, 5}: red, {6, 6}: {}}},
+		{"hexagonal", "top", 4, 0, map[image.Point]color.NRGBA{{1, 0}: {}, {2, 0}: red, {5, 3}: red, {6, 0}: {}}},
+		// Isometric objects are projected first, (0, 0) being the top
+		// corner of the map at (8, 0).
+		{"isometric", "topleft", 0, 0, map[image.Point]color.NRGBA{{7, 0}: {}, {8, 0}: red, {11, 3}: red, {12, 0}: {}}},
+		{"staggered", "topright", 8, 0, map[image.Point]color.NRGBA{{3, 0}: {}, {4, 0}: red, {7, 3}: red, {8, 0}: {}}},
+	}
+	for _, tt := range tests {
+		tw, th := 8, 8
+		if tt.orientation == "isometric" {
+			th = 4
+		}
+		r := loadTestMap(t, fmt.Sprintf(tmx, tt.orientation, tw, th, tt.alignment, tt.x, tt.y), files)
+		if err := r.RenderVisibleObjectGroups(); err != nil {
+			t.Fatal(err)
+		}
+		checkPixels(t, tt.orientation+" "+tt.alignment, r.Result, tt.want)
+	}
+}
+
+func TestRenderer_UnspecifiedObjectAlignment(t *testing.T) {
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 4, red)}}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="%s" renderorder="right-down" width="2" height="2" tilewidth="8" tileheight="8" hexsidelength="4" staggeraxis="y" staggerindex="odd">
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="1" columns="1" %s>
+  <image source="tiles.png" width="4" height="4"/>
+ </tileset>
+ <objectgroup id="1" name="objects">
+  <object id="1" gid="1" x="4" y="8" width="4" height="4"/>
+ </objectgroup>
+</map>`
+
+	// An unspecified alignment is the orientation's default one.
+	tests := []struct {
+		orientation, alignment string
+	}{
+		{"orthogonal", "bottomleft"},
+		{"isometric", "bottom"},
+		{"staggered", "bottomleft"},
+		{"hexagonal", "bottomleft"},
+	}
+	for _, tt := range tests {
+		render := func(attrs string) *image.NRGBA {
+			r := loadTestMap(t, fmt.Sprintf(tmx, tt.orientation, attrs), files)
+			if err := r.RenderVisibleObjectGroups(); err != nil {
+				t.Fatal(err)
+			}
+			return r.Result
+		}
+		want := render(`objectalignment="` + tt.alignment + `"`)
+		for _, attrs := range []string{"", `objectalignment="unspecified"`} {
+			if got := render(attrs); !bytes.Equal(got.Pix, want.Pix) {
+				t.Errorf("%s %q: result differs from %s alignment", tt.orientation, attrs, tt.alignment)
+			}
+		}
+	}
+
+	// On orthogonal maps, the object's image covers the rows just above its
+	// position.
+	r := loadTestMap(t, fmt.Sprintf(tmx, "orthogonal", ""), files)
+	if err := r.RenderVisibleObjectGroups(); err != nil {
+		t.Fatal(err)
+	}
+	checkPixels(t, "unspecified", r.Result, map[image.Point]color.NRGBA{
+		{4, 3}: {}, {4, 4}: red, {7, 7}: red, {4, 8}: {},
+	})
+}
+
+// TestRenderer_ObjectAlignmentReference renders one tile object for each
+// object alignment and compares the result with assets/object_alignment.png,
+// which draws each 8x8 image at the object's position minus the alignment's
+// fraction of its size, as Tiled places them.
+func TestRenderer_ObjectAlignmentReference(t *testing.T) {
+	m, err := tiled.LoadFile("../assets/object_alignment.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRenderer(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenderVisibleObjectGroups(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open("../assets/object_alignment.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	want, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareImages(t, r.Result, want, 0)
+}
+
+func TestRenderer_CollectionSubRectangles(t *testing.T) {
+	files := fstest.MapFS{
+		"sheet.png": {Data: solidTiles(t, 4, red, green, blue)},
+		"whole.png": {Data: solidTiles(t, 4, red)},
+	}
+	// The size of the last image isn't saved, so its tile covers it whole.
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="3" height="1" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="sprites" tilewidth="4" tileheight="4" tilecount="3" columns="0">
+  <grid orientation="orthogonal" width="1" height="1"/>
+  <tile id="0" x="4" width="4" height="4">
+   <image source="sheet.png" width="12" height="4"/>
+  </tile>
+  <tile id="1" x="8" width="4" height="4">
+   <image source="sheet.png" width="12" height="4"/>
+  </tile>
+  <tile id="2">
+   <image source="whole.png"/>
+  </tile>
+ </tileset>
+ <layer id="1" name="tiles" width="3" height="1"><data encoding="csv">1,2,3</data></layer>
+</map>`
+
+	r := loadTestMap(t, tmx, files)
+	if err := r.RenderVisibleLayers(); err != nil {
+		t.Fatal(err)
+	}
+	checkPixels(t, "layer", r.Result, map[image.Point]color.NRGBA{
+		{0, 0}: green, {3, 3}: green, {4, 0}: blue, {7, 3}: blue, {8, 0}: red, {11, 3}: red,
+	})
+}
+
+func TestRenderer_TilesetTrans(t *testing.T) {
+	magenta := color.NRGBA{255, 0, 255, 255}
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 4, red, magenta)}}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="2" height="1" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="2" columns="2">
+  <image source="tiles.png" trans="ff00ff" width="8" height="4"/>
+ </tileset>
+ <layer id="1" name="tiles" width="2" height="1"><data encoding="csv">1,2</data></layer>
+</map>`
+
+	r := loadTestMap(t, tmx, files)
+	if err := r.RenderVisibleLayers(); err != nil {
+		t.Fatal(err)
+	}
+	checkPixels(t, "layer", r.Result, map[image.Point]color.NRGBA{
+		{0, 0}: red, {4, 0}: {}, {7, 3}: {},
+	})
+}
+
+func TestRenderer_WithImageDecoder(t *testing.T) {
+	// The decoder of the made up format ignores the file, which isn't an
+	// image the image package could decode.
+	files := fstest.MapFS{"tiles.SOLID": {Data: []byte("solid")}}
+	decode := func(rd io.Reader) (image.Image, error) {
+		return image.NewUniform(blue), nil
+	}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="1" height="1" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="1" columns="1">
+  <image source="tiles.SOLID" width="4" height="4"/>
+ </tileset>
+ <layer id="1" name="tiles" width="1" height="1"><data encoding="csv">1</data></layer>
+</map>`
+
+	r := loadTestMap(t, tmx, files)
+	if err := r.RenderVisibleLayers(); err == nil {
+		t.Fatal("rendering without the decoder succeeded")
+	}
+
+	r = loadTestMap(t, tmx, files)
+	WithImageDecoder("solid", decode)(r)
+	if err := r.RenderVisibleLayers(); err != nil {
+		t.Fatal(err)
+	}
+	checkPixels(t, "layer", r.Result, map[image.Point]color.NRGBA{
+		{0, 0}: blue, {3, 3}: blue,
+	})
+}
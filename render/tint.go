@@ -0,0 +1,116 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// renderTint calls render with the tint color c applied to the tiles and
+// images it draws, multiplied with the tint colors of the groups being
+// rendered.
+func (r *Renderer) renderTint(c *tiled.HexColor, render func() error) error {
+	if c == nil {
+		return render()
+	}
+
+	tint := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if r.tint != nil {
+		tint = color.NRGBA{
+			R: mul8(r.tint.R, tint.R),
+			G: mul8(r.tint.G, tint.G),
+			B: mul8(r.tint.B, tint.B),
+			A: mul8(r.tint.A, tint.A),
+		}
+	}
+
+	prev := r.tint
+	r.tint = &tint
+	defer func() { r.tint = prev }()
+
+	return render()
+}
+
+// tinted returns img with each of its color and alpha components multiplied
+// by those of the current tint color.
+func (r *Renderer) tinted(img image.Image) image.Image {
+	if r.tint == nil || *r.tint == (color.NRGBA{R: 255, G: 255, B: 255, A: 255}) {
+		return img
+	}
+
+	b := img.Bounds()
+	dst := image.NewNRGBA(b)
+	draw.Draw(dst, b, img, b.Min, draw.Src)
+	t := *r.tint
+	for i := 0; i < len(dst.Pix); i += 4 {
+		p := dst.Pix[i : i+4 : i+4]
+		p[0], p[1], p[2], p[3] = mul8(p[0], t.R), mul8(p[1], t.G), mul8(p[2], t.B), mul8(p[3], t.A)
+	}
+	return dst
+}
+
+// tintedTile returns img, the image of tile fitted into a grid cell if grid
+// is set, with the current tint color applied. Tinted images are kept in the
+// tile image cache, as the same tiles are drawn over and over.
+func (r *Renderer) tintedTile(tile *tiled.LayerTile, img image.Image, grid bool) (image.Image, error) {
+	if r.tint == nil || *r.tint == (color.NRGBA{R: 255, G: 255, B: 255, A: 255}) {
+		return img, nil
+	}
+
+	tileKey, err := r.getTileImageKey(tile)
+	if err != nil {
+		return nil, err
+	}
+	key := tintedTileKey{tile: fittedTileKey{tile: tileKey}, fitted: grid, tint: *r.tint}
+	if grid {
+		key.tile = r.fittedTileKey(tile, tileKey)
+	}
+	if tinted, ok := r.cache.cached(key); ok {
+		return tinted, nil
+	}
+	return r.cache.load(key, func() (image.Image, error) {
+		return r.tinted(img), nil
+	})
+}
+
+// tintedImage returns img, the image identified by key, with the current tint
+// color applied. Like tinted tiles, it's kept in the tile image cache, so that
+// an image layer isn't tinted again every time it's rendered.
+func (r *Renderer) tintedImage(key sourceImageKey, img image.Image) (image.Image, error) {
+	if r.tint == nil || *r.tint == (color.NRGBA{R: 255, G: 255, B: 255, A: 255}) {
+		return img, nil
+	}
+
+	return r.cache.load(tintedImageKey{source: key, tint: *r.tint}, func() (image.Image, error) {
+		return r.tinted(img), nil
+	})
+}
+
+// mul8 multiplies two 8-bit color components, rounding to the nearest.
+func mul8(a, b uint8) uint8 {
+	return uint8((uint16(a)*uint16(b) + 127) / 255)
+}
@@ -0,0 +1,245 @@
+/*
+Copyright (c) 2017 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lafriks/go-tiled"
+)
+
+func TestRenderer_TintColor(t *testing.T) {
+	white := color.NRGBA{255, 255, 255, 255}
+	files := fstest.MapFS{
+		"tiles.png": {Data: solidTiles(t, 4, white)},
+		"image.png": {Data: solidTiles(t, 4, white)},
+	}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="3" height="1" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="1" columns="1">
+  <image source="tiles.png" width="4" height="4"/>
+ </tileset>
+ <group id="1" name="group"%s>
+  <layer id="2" name="tiles" width="3" height="1" tintcolor="#ff8000"><data encoding="csv">1,0,0</data></layer>
+  <objectgroup id="3" name="objects" tintcolor="#80ffffff">
+   <object id="1" gid="1" x="4" y="4" width="4" height="4"/>
+  </objectgroup>
+  <imagelayer id="4" name="image" offsetx="8" tintcolor="#0000ff">
+   <image source="image.png" width="4" height="4"/>
+  </imagelayer>
+ </group>
+</map>`
+
+	// Translucent pixels lose some precision being drawn onto the result.
+	tests := []struct {
+		name  string
+		attrs string
+		want  [3]color.NRGBA
+	}{
+		{
+			name: "layers",
+			want: [3]color.NRGBA{{255, 128, 0, 255}, {253, 253, 253, 128}, {0, 0, 255, 255}},
+		},
+		{
+			name:  "compounded with group",
+			attrs: ` tintcolor="#808080"`,
+			want:  [3]color.NRGBA{{128, 64, 0, 255}, {127, 127, 127, 128}, {0, 0, 128, 255}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := loadTestMap(t, fmt.Sprintf(tmx, tt.attrs), files)
+			if err := r.RenderMap(); err != nil {
+				t.Fatal(err)
+			}
+			checkPixels(t, tt.name, r.Result, map[image.Point]color.NRGBA{
+				{1, 1}: tt.want[0], {5, 1}: tt.want[1], {9, 1}: tt.want[2],
+			})
+		})
+	}
+}
+
+// cachedTintedTiles returns the number of tinted tile images in cache.
+func cachedTintedTiles(cache *TileImageCache) int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	n := 0
+	for key := range cache.entries {
+		if _, ok := key.(tintedTileKey); ok {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRenderer_TintCache(t *testing.T) {
+	files := fstest.MapFS{"tiles.png": {Data: solidTiles(t, 4, red, green)}}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="4" height="1" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4" tilecount="2" columns="2">
+  <image source="tiles.png" width="8" height="4"/>
+ </tileset>
+ <layer id="1" name="tinted" width="4" height="1" tintcolor="#808080"><data encoding="csv">1,2,1,2</data></layer>
+ <layer id="2" name="half" width="4" height="1" tintcolor="#80ffffff" visible="0"><data encoding="csv">1,1,1,1</data></layer>
+</map>`
+
+	r := loadTestMap(t, tmx, files)
+	if err := r.RenderMap(); err != nil {
+		t.Fatal(err)
+	}
+	// Each tile is tinted once, however often it's drawn.
+	if got := cachedTintedTiles(r.cache); got != 2 {
+		t.Errorf("tinted tile images = %d, want 2", got)
+	}
+	checkPixels(t, "tinted", r.Result, map[image.Point]color.NRGBA{
+		{0, 0}: {128, 0, 0, 255}, {4, 0}: {0, 128, 0, 255}, {8, 0}: {128, 0, 0, 255},
+	})
+
+	// The same tile with another tint is cached separately.
+	r.m.Layers[1].Visible = true
+	r.Clear()
+	if err := r.RenderMap(); err != nil {
+		t.Fatal(err)
+	}
+	if got := cachedTintedTiles(r.cache); got != 3 {
+		t.Errorf("tinted tile images = %d, want 3", got)
+	}
+	allocs := testing.AllocsPerRun(5, func() {
+		r.Clear()
+		if err := r.RenderMap(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	r.m.Layers[0].TintColor, r.m.Layers[1].TintColor = nil, nil
+	untinted := testing.AllocsPerRun(5, func() {
+		r.Clear()
+		if err := r.RenderMap(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// Tinting a layer has a small fixed cost, not one for each of the 8
+	// tiles drawn.
+	if allocs >= untinted+8 {
+		t.Errorf("tinted render allocates %v times, untinted %v", allocs, untinted)
+	}
+}
+
+func TestRenderer_SharedTintedTiles(t *testing.T) {
+	// One 8x4 tile, fitted into 4x4 grid cells and tinted.
+	files := fstest.MapFS{"wide.png": {Data: solidTiles(t, 4, red, red)}}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="2" height="1" tilewidth="4" tileheight="4">
+ <tileset firstgid="1" name="wide" tilewidth="8" tileheight="4" tilecount="1" columns="1" tilerendersize="grid">
+  <image source="wide.png" width="8" height="4"/>
+ </tileset>
+ <layer id="1" name="tiles" width="2" height="1" tintcolor="#808080"><data encoding="csv">1,1</data></layer>
+</map>`
+
+	for _, maxBytes := range []int64{0, 200} {
+		cache := NewTileImageCache(maxBytes)
+		var images int
+		for i := 0; i < 2; i++ {
+			m, err := tiled.LoadReader(".", strings.NewReader(tmx), tiled.WithFileSystem(files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := NewRendererWithFileSystem(m, files, WithTileImageCache(cache))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.RenderMap(); err != nil {
+				t.Fatal(err)
+			}
+			checkPixels(t, "tinted", r.Result, map[image.Point]color.NRGBA{
+				{0, 0}: {128, 0, 0, 255}, {7, 3}: {128, 0, 0, 255},
+			})
+
+			// The second renderer reuses the fitted and tinted tiles of the
+			// first.
+			if i == 0 {
+				images = cache.Len()
+			} else if got := cache.Len(); got != images {
+				t.Errorf("maxBytes %d: shared cache holds %d images, want %d", maxBytes, got, images)
+			}
+		}
+		if maxBytes == 0 && cachedTintedTiles(cache) != 1 {
+			t.Errorf("tinted tile images = %d, want 1", cachedTintedTiles(cache))
+		}
+		if maxBytes > 0 && cache.Size() > maxBytes {
+			t.Errorf("cache size = %d, want at most %d", cache.Size(), maxBytes)
+		}
+	}
+}
+
+func TestRenderer_TintedImageLayerCache(t *testing.T) {
+	files := fstest.MapFS{"image.png": {Data: solidTiles(t, 4, red)}}
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="2" height="1" tilewidth="4" tileheight="4">
+ <imagelayer id="1" name="image" tintcolor="#808080" repeatx="1">
+  <image source="image.png" width="4" height="4"/>
+ </imagelayer>
+</map>`
+
+	r := loadTestMap(t, tmx, files)
+	tinted := func() int {
+		r.cache.mu.Lock()
+		defer r.cache.mu.Unlock()
+		n := 0
+		for key := range r.cache.entries {
+			if _, ok := key.(tintedImageKey); ok {
+				n++
+			}
+		}
+		return n
+	}
+
+	// The image is tinted once, however often it's rendered.
+	for i := 0; i < 2; i++ {
+		r.Clear()
+		if err := r.RenderMap(); err != nil {
+			t.Fatal(err)
+		}
+		checkPixels(t, "tinted", r.Result, map[image.Point]color.NRGBA{
+			{0, 0}: {128, 0, 0, 255}, {7, 3}: {128, 0, 0, 255},
+		})
+		if got := tinted(); got != 1 {
+			t.Errorf("render %d: tinted images = %d, want 1", i+1, got)
+		}
+	}
+
+	// The same image with another tint is cached separately.
+	r.m.ImageLayers[0].TintColor = tiled.NewHexColor(255, 255, 255, 128)
+	r.Clear()
+	if err := r.RenderMap(); err != nil {
+		t.Fatal(err)
+	}
+	if got := tinted(); got != 2 {
+		t.Errorf("tinted images = %d, want 2", got)
+	}
+}
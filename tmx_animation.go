@@ -0,0 +1,139 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import "time"
+
+// IsAnimated reports whether the tile has an animation.
+func (t *TilesetTile) IsAnimated() bool {
+	return len(t.Animation) > 0
+}
+
+// AnimationDuration returns the length of one cycle of the tile's animation,
+// or 0 if the tile isn't animated.
+func (t *TilesetTile) AnimationDuration() time.Duration {
+	var d time.Duration
+	for _, f := range t.Animation {
+		d += time.Duration(f.Duration) * time.Millisecond
+	}
+	return d
+}
+
+// FrameIndexAt returns the index into Animation of the frame shown at the
+// given time since the animation started. Animations loop, so any elapsed
+// time, including negative ones, maps to a frame. Returns -1 if the tile isn't
+// animated.
+func (t *TilesetTile) FrameIndexAt(elapsed time.Duration) int {
+	if len(t.Animation) == 0 {
+		return -1
+	}
+
+	total := t.AnimationDuration()
+	if total <= 0 {
+		return 0
+	}
+	elapsed %= total
+	if elapsed < 0 {
+		elapsed += total
+	}
+
+	for i, f := range t.Animation {
+		d := time.Duration(f.Duration) * time.Millisecond
+		if elapsed < d {
+			return i
+		}
+		elapsed -= d
+	}
+	return len(t.Animation) - 1
+}
+
+// FrameAt returns the local ID of the tile shown at the given time since the
+// animation started, which is the tile's own ID if it isn't animated.
+func (t *TilesetTile) FrameAt(elapsed time.Duration) uint32 {
+	i := t.FrameIndexAt(elapsed)
+	if i < 0 {
+		return t.ID
+	}
+	return t.Animation[i].TileID
+}
+
+// TileIDAt returns the local ID of the tile shown in place of the tile with
+// the given local ID at the given time since its animation started, which is
+// tileID itself unless the tile is animated.
+func (ts *Tileset) TileIDAt(tileID uint32, elapsed time.Duration) uint32 {
+	t, err := ts.GetTilesetTile(tileID)
+	if err != nil {
+		return tileID
+	}
+	return t.FrameAt(elapsed)
+}
+
+// AnimatedCell is a cell of a layer holding an animated tile.
+type AnimatedCell struct {
+	// Position of the cell in tiles.
+	X, Y int
+	// Tile placed in the cell.
+	Tile *LayerTile
+	// TilesetTile holds the animation of the tile.
+	TilesetTile *TilesetTile
+}
+
+// AnimatedCells returns the cells of the layer that hold animated tiles, in
+// the order they're stored in, so that only those need to be updated as time
+// passes.
+func (l *Layer) AnimatedCells() []AnimatedCell {
+	if l._map == nil || l._map.Width == 0 {
+		return nil
+	}
+
+	type tileKey struct {
+		ts *Tileset
+		id uint32
+	}
+	animated := make(map[tileKey]*TilesetTile)
+	indexed := make(map[*Tileset]bool)
+
+	var cells []AnimatedCell
+	for i, tile := range l.Tiles {
+		if tile == nil || tile.IsNil() || tile.Tileset == nil {
+			continue
+		}
+		if !indexed[tile.Tileset] {
+			indexed[tile.Tileset] = true
+			for _, t := range tile.Tileset.Tiles {
+				if t.IsAnimated() {
+					animated[tileKey{tile.Tileset, t.ID}] = t
+				}
+			}
+		}
+		if t, ok := animated[tileKey{tile.Tileset, tile.ID}]; ok {
+			cells = append(cells, AnimatedCell{
+				X:           i % l._map.Width,
+				Y:           i / l._map.Width,
+				Tile:        tile,
+				TilesetTile: t,
+			})
+		}
+	}
+	return cells
+}
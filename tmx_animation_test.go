@@ -0,0 +1,117 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAnimationMap = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="3" height="2" tilewidth="16" tileheight="16" nextlayerid="2" nextobjectid="1">
+ <tileset firstgid="1" name="animated" tilewidth="16" tileheight="16" tilecount="8" columns="4">
+  <image source="tilesets/tileset.png" width="64" height="32"/>
+  <tile id="0">
+   <animation>
+    <frame tileid="4" duration="100"/>
+    <frame tileid="5" duration="300"/>
+    <frame tileid="6" duration="100"/>
+   </animation>
+  </tile>
+  <tile id="1">
+   <properties>
+    <property name="still" type="bool" value="true"/>
+   </properties>
+  </tile>
+ </tileset>
+ <layer id="1" name="Ground" width="3" height="2">
+  <data encoding="csv">
+1,2,0,
+0,3,1
+</data>
+ </layer>
+</map>
+`
+
+func TestTilesetTile_FrameAt(t *testing.T) {
+	m, err := LoadReader(GetAssetsDirectory(), strings.NewReader(testAnimationMap))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ts := m.Tilesets[0]
+	tile, err := ts.GetTilesetTile(0)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, tile.IsAnimated())
+	assert.Equal(t, 500*time.Millisecond, tile.AnimationDuration())
+
+	tests := []struct {
+		elapsed time.Duration
+		want    uint32
+	}{
+		{0, 4},
+		{99 * time.Millisecond, 4},
+		{100 * time.Millisecond, 5},
+		{399 * time.Millisecond, 5},
+		{400 * time.Millisecond, 6},
+		{500 * time.Millisecond, 4},
+		{1150 * time.Millisecond, 5},
+		{-50 * time.Millisecond, 6},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tile.FrameAt(tt.elapsed), "elapsed %v", tt.elapsed)
+		assert.Equal(t, tt.want, ts.TileIDAt(0, tt.elapsed), "elapsed %v", tt.elapsed)
+	}
+
+	still, err := ts.GetTilesetTile(1)
+	if assert.NoError(t, err) {
+		assert.False(t, still.IsAnimated())
+		assert.Equal(t, time.Duration(0), still.AnimationDuration())
+		assert.Equal(t, -1, still.FrameIndexAt(time.Second))
+		assert.Equal(t, uint32(1), still.FrameAt(time.Second))
+	}
+	assert.Equal(t, uint32(7), ts.TileIDAt(7, time.Second))
+}
+
+func TestLayer_AnimatedCells(t *testing.T) {
+	m, err := LoadReader(GetAssetsDirectory(), strings.NewReader(testAnimationMap))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cells := m.Layers[0].AnimatedCells()
+	if assert.Len(t, cells, 2) {
+		assert.Equal(t, 0, cells[0].X)
+		assert.Equal(t, 0, cells[0].Y)
+		assert.Equal(t, 2, cells[1].X)
+		assert.Equal(t, 1, cells[1].Y)
+		assert.Equal(t, uint32(0), cells[1].Tile.ID)
+		assert.Equal(t, uint32(6), cells[1].TilesetTile.FrameAt(450*time.Millisecond))
+	}
+}
@@ -0,0 +1,471 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrUnsupportedOrientation error is returned when an operation isn't
+// supported for the map's orientation. The render and navigation packages
+// return it too.
+var ErrUnsupportedOrientation = errors.New("tiled: unsupported orientation")
+
+// DefaultSolidProperty is the name of the boolean tile property that marks the
+// whole tile as solid when extracting collision geometry.
+const DefaultSolidProperty = "solid"
+
+// Collision is the collision geometry of a layer, in pixel coordinates.
+type Collision struct {
+	// Rects are the axis-aligned solid areas, with adjacent ones merged.
+	Rects []Rect
+	// Polygons are the remaining solid areas: ellipses, rotated rectangles
+	// and polygons, as well as the whole cells of solid tiles on staggered
+	// and hexagonal maps. Polygons sharing a whole edge are merged, but
+	// they're never merged with Rects. Ellipses are approximated with
+	// DefaultEllipseSegments vertices.
+	Polygons [][]Point
+	// Polylines are the open collision lines, such as one-way platforms.
+	Polylines [][]Point
+}
+
+// CollisionOption is used to customize collision geometry extraction
+type CollisionOption func(*collisionOptions)
+
+type collisionOptions struct {
+	solidProperty string
+}
+
+// WithSolidProperty sets the name of the boolean tile property that marks the
+// whole tile as solid. An empty name disables the property, so that only the
+// collision shapes of tiles are used.
+func WithSolidProperty(name string) CollisionOption {
+	return func(o *collisionOptions) {
+		o.solidProperty = name
+	}
+}
+
+// tileCollision is the collision geometry of a single unflipped tile,
+// relative to the top-left corner of its image.
+type tileCollision struct {
+	width, height float64
+	solid         bool
+	rects         []Rect
+	polygons      [][]Point
+	polylines     [][]Point
+}
+
+// Collision extracts the collision geometry of the layer's tiles.
+//
+// A tile contributes its whole area if it has the solid property set (see
+// WithSolidProperty), and the shapes in its TilesetTile.ObjectGroups
+// otherwise. The whole area is the tile's image on orthogonal maps, and its
+// cell on other orientations: a square in the pixel coordinates of isometric
+// maps, and a diamond or hexagon on staggered and hexagonal ones.
+//
+// The shapes of each tile are flipped like the tile and moved to where its
+// image is drawn, taking the tileset's TileOffset into account, and then
+// converted from screen to pixel coordinates (see Map.ScreenToPixel), which
+// turns rectangles into polygons on isometric maps. Adjacent rectangles are
+// merged into as few as possible, and so are polygons sharing an edge. Point
+// objects are ignored. Like object positions, the geometry doesn't include the
+// layer offset.
+//
+// ErrUnsupportedOrientation is returned for unknown orientations.
+func (l *Layer) Collision(options ...CollisionOption) (*Collision, error) {
+	opts := &collisionOptions{solidProperty: DefaultSolidProperty}
+	for _, o := range options {
+		o(opts)
+	}
+
+	m := l._map
+	if m == nil {
+		return nil, ErrUnsupportedOrientation
+	}
+	switch m.Orientation {
+	case "orthogonal", "isometric", "staggered", "hexagonal":
+	default:
+		return nil, ErrUnsupportedOrientation
+	}
+
+	type tileKey struct {
+		ts *Tileset
+		id uint32
+	}
+	cache := make(map[tileKey]*tileCollision)
+
+	toPixel := func(p Point) Point {
+		p.X, p.Y = m.ScreenToPixel(p.X, p.Y)
+		return p
+	}
+	toPixelAll := func(points []Point) []Point {
+		l := make([]Point, len(points))
+		for i, p := range points {
+			l[i] = toPixel(p)
+		}
+		return l
+	}
+
+	c := &Collision{}
+	th := float64(m.TileHeight)
+	for i, tile := range l.Tiles {
+		if tile == nil || tile.IsNil() || tile.Tileset == nil {
+			continue
+		}
+
+		key := tileKey{tile.Tileset, tile.ID}
+		tc, ok := cache[key]
+		if !ok {
+			tc = newTileCollision(tile.Tileset, tile.ID, opts)
+			cache[key] = tc
+		}
+		if !tc.solid && len(tc.rects) == 0 && len(tc.polygons) == 0 && len(tc.polylines) == 0 {
+			continue
+		}
+
+		x, y := i%m.Width, i/m.Width
+		if tc.solid && m.Orientation != "orthogonal" {
+			if m.Orientation == "isometric" {
+				c.Rects = append(c.Rects, NewRect(float64(x)*th, float64(y)*th, th, th))
+			} else {
+				c.Polygons = append(c.Polygons, m.cellPolygon(x, y))
+			}
+			continue
+		}
+
+		// Tiles are drawn with the bottom-left corner of their image at the
+		// bottom-left corner of their cell's bounding box.
+		w, h := tc.width, tc.height
+		if tile.DiagonalFlip {
+			w, h = h, w
+		}
+		ox, oy := m.TileToScreen(x, y)
+		oy += th - h
+		if offset := tile.Tileset.TileOffset; offset != nil {
+			ox += float64(offset.X)
+			oy += float64(offset.Y)
+		}
+
+		transform := func(p Point) Point {
+			if tile.DiagonalFlip {
+				p.X, p.Y = p.Y, p.X
+			}
+			if tile.HorizontalFlip {
+				p.X = w - p.X
+			}
+			if tile.VerticalFlip {
+				p.Y = h - p.Y
+			}
+			return Point{X: ox + p.X, Y: oy + p.Y}
+		}
+		transformAll := func(points []Point) []Point {
+			l := make([]Point, len(points))
+			for i, p := range points {
+				l[i] = toPixel(transform(p))
+			}
+			return l
+		}
+
+		rects := tc.rects
+		if tc.solid {
+			rects = []Rect{NewRect(0, 0, tc.width, tc.height)}
+		}
+		for _, r := range rects {
+			box := boundsOf([]Point{transform(r.Min), transform(r.Max)})
+			if m.Orientation == "isometric" {
+				c.Polygons = append(c.Polygons, toPixelAll([]Point{
+					box.Min, {X: box.Max.X, Y: box.Min.Y}, box.Max, {X: box.Min.X, Y: box.Max.Y},
+				}))
+				continue
+			}
+			c.Rects = append(c.Rects, box)
+		}
+		for _, p := range tc.polygons {
+			c.Polygons = append(c.Polygons, transformAll(p))
+		}
+		for _, p := range tc.polylines {
+			c.Polylines = append(c.Polylines, transformAll(p))
+		}
+	}
+
+	c.Rects = mergeRects(c.Rects)
+	c.Polygons = mergePolygons(c.Polygons)
+	return c, nil
+}
+
+// cellPolygon returns the outline of the hexagon of tile (x, y) of a
+// staggered or hexagonal map, in screen coordinates, as in Tiled's
+// HexagonalRenderer::tileToScreenPolygon. The hexagons of staggered maps
+// have no sides, making them diamonds.
+func (m *Map) cellPolygon(x, y int) []Point {
+	p := m.staggerParams()
+	sx, sy := p.tileToScreen(x, y)
+	tw, th := float64(p.tileWidth&^1), float64(p.tileHeight&^1)
+	ox, oy := float64(p.sideOffsetX), float64(p.sideOffsetY)
+
+	corners := []Point{
+		{X: 0, Y: th - oy}, {X: 0, Y: oy}, {X: ox, Y: 0}, {X: tw - ox, Y: 0},
+		{X: tw, Y: oy}, {X: tw, Y: th - oy}, {X: tw - ox, Y: th}, {X: ox, Y: th},
+	}
+	poly := make([]Point, 0, len(corners))
+	for _, c := range corners {
+		c = Point{X: sx + c.X, Y: sy + c.Y}
+		if len(poly) == 0 || poly[len(poly)-1] != c {
+			poly = append(poly, c)
+		}
+	}
+	if len(poly) > 1 && poly[0] == poly[len(poly)-1] {
+		poly = poly[:len(poly)-1]
+	}
+	return poly
+}
+
+// newTileCollision collects the collision geometry of a tileset tile.
+func newTileCollision(ts *Tileset, id uint32, opts *collisionOptions) *tileCollision {
+	tc := &tileCollision{width: float64(ts.TileWidth), height: float64(ts.TileHeight)}
+
+	t, err := ts.GetTilesetTile(id)
+	if err != nil {
+		return tc
+	}
+	if t.Image != nil && t.Image.Width > 0 && t.Image.Height > 0 {
+		tc.width, tc.height = float64(t.Image.Width), float64(t.Image.Height)
+	}
+	if t.Width > 0 && t.Height > 0 {
+		tc.width, tc.height = float64(t.Width), float64(t.Height)
+	}
+
+	if opts.solidProperty != "" && t.Properties.GetBool(opts.solidProperty) {
+		tc.solid = true
+		return tc
+	}
+
+	for _, og := range t.ObjectGroups {
+		for _, o := range og.Objects {
+			s := o.Shape()
+			switch s.Kind {
+			case ShapePoint:
+			case ShapePolyline:
+				tc.polylines = append(tc.polylines, s.Vertices())
+			case ShapeRectangle, ShapeTile:
+				if s.Box.Dx() <= 0 || s.Box.Dy() <= 0 {
+					continue
+				}
+				if math.Mod(s.Rotation, 90) == 0 {
+					tc.rects = append(tc.rects, s.Bounds())
+					continue
+				}
+				tc.polygons = append(tc.polygons, s.Vertices())
+			default:
+				if s.Kind == ShapePolygon && len(s.Points) < 3 {
+					continue
+				}
+				tc.polygons = append(tc.polygons, s.Vertices())
+			}
+		}
+	}
+	return tc
+}
+
+// mergeRects merges rectangles that touch along a whole side, first into
+// horizontal runs and then stacking runs of the same width vertically.
+func mergeRects(rects []Rect) []Rect {
+	const eps = 1e-9
+	near := func(a, b float64) bool {
+		return math.Abs(a-b) <= eps
+	}
+
+	merge := func(rects []Rect, horizontal bool) []Rect {
+		// Project the rectangles so that merging always happens along x.
+		if !horizontal {
+			for i, r := range rects {
+				rects[i] = Rect{Min: Point{X: r.Min.Y, Y: r.Min.X}, Max: Point{X: r.Max.Y, Y: r.Max.X}}
+			}
+		}
+		sort.Slice(rects, func(i, j int) bool {
+			a, b := rects[i], rects[j]
+			if !near(a.Min.Y, b.Min.Y) {
+				return a.Min.Y < b.Min.Y
+			}
+			if !near(a.Max.Y, b.Max.Y) {
+				return a.Max.Y < b.Max.Y
+			}
+			return a.Min.X < b.Min.X
+		})
+
+		var merged []Rect
+		for _, r := range rects {
+			if n := len(merged); n > 0 {
+				last := &merged[n-1]
+				if near(last.Min.Y, r.Min.Y) && near(last.Max.Y, r.Max.Y) && r.Min.X <= last.Max.X+eps {
+					last.Max.X = math.Max(last.Max.X, r.Max.X)
+					continue
+				}
+			}
+			merged = append(merged, r)
+		}
+
+		if !horizontal {
+			for i, r := range merged {
+				merged[i] = Rect{Min: Point{X: r.Min.Y, Y: r.Min.X}, Max: Point{X: r.Max.Y, Y: r.Max.X}}
+			}
+		}
+		return merged
+	}
+
+	if len(rects) < 2 {
+		return rects
+	}
+	return merge(merge(rects, true), false)
+}
+
+// mergePolygons merges polygons that share a whole edge, in opposite
+// directions once they're all wound the same way, by joining their outlines
+// along it. Polygons enclosing others end up with an outline running to the
+// inner boundary and back along the same edge.
+func mergePolygons(polygons [][]Point) [][]Point {
+	if len(polygons) < 2 {
+		return polygons
+	}
+
+	// Vertices are matched with some tolerance, as those of neighbouring
+	// tiles are computed from different origins.
+	type vertex [2]int64
+	type edge [2]vertex
+	key := func(p Point) vertex {
+		return vertex{int64(math.Round(p.X * 1e6)), int64(math.Round(p.Y * 1e6))}
+	}
+
+	polys := make([][]Point, len(polygons))
+	owner := make(map[edge]int)
+	for i, poly := range polygons {
+		if polygonArea(poly) < 0 {
+			poly = append([]Point(nil), poly...)
+			for a, b := 0, len(poly)-1; a < b; a, b = a+1, b-1 {
+				poly[a], poly[b] = poly[b], poly[a]
+			}
+		}
+		polys[i] = poly
+		for j := range poly {
+			owner[edge{key(poly[j]), key(poly[(j+1)%len(poly)])}] = i
+		}
+	}
+
+	for i := range polys {
+	scan:
+		for polys[i] != nil {
+			poly := polys[i]
+			for a := range poly {
+				p, q := poly[a], poly[(a+1)%len(poly)]
+				o, ok := owner[edge{key(q), key(p)}]
+				if !ok || o == i {
+					continue
+				}
+				other := polys[o]
+				b := 0
+				for key(other[b]) != key(q) || key(other[(b+1)%len(other)]) != key(p) {
+					b++
+				}
+
+				// Walk this polygon from q around to p, and the other one
+				// from p around to just before q.
+				merged := make([]Point, 0, len(poly)+len(other)-2)
+				for k := 1; k <= len(poly); k++ {
+					merged = append(merged, poly[(a+k)%len(poly)])
+				}
+				for k := 2; k < len(other); k++ {
+					merged = append(merged, other[(b+k)%len(other)])
+				}
+
+				delete(owner, edge{key(p), key(q)})
+				delete(owner, edge{key(q), key(p)})
+				for k := range other {
+					e := edge{key(other[k]), key(other[(k+1)%len(other)])}
+					if owner[e] == o {
+						owner[e] = i
+					}
+				}
+				polys[i], polys[o] = merged, nil
+				continue scan
+			}
+			break
+		}
+	}
+
+	merged := polygons[:0]
+	for _, poly := range polys {
+		if poly != nil {
+			merged = append(merged, simplifyPolygon(poly))
+		}
+	}
+	return merged
+}
+
+// simplifyPolygon removes the spikes left by merging polygons along
+// consecutive edges, and the vertices in the middle of straight sides.
+func simplifyPolygon(poly []Point) []Point {
+	const eps = 1e-6
+	near := func(a, b Point) bool {
+		return math.Abs(a.X-b.X) <= eps && math.Abs(a.Y-b.Y) <= eps
+	}
+
+	for changed := true; changed && len(poly) > 3; {
+		changed = false
+		for i := range poly {
+			prev, cur, next := poly[(i+len(poly)-1)%len(poly)], poly[i], poly[(i+1)%len(poly)]
+			spike := near(prev, next)
+			straight := math.Abs(cross(prev, cur, next)) <= eps &&
+				(cur.X-prev.X)*(next.X-cur.X)+(cur.Y-prev.Y)*(next.Y-cur.Y) > 0
+			if !spike && !straight && !near(prev, cur) {
+				continue
+			}
+			if spike {
+				// Drop the tip and one of the two copies of its base.
+				j := (i + 1) % len(poly)
+				poly = append(poly[:i:i], poly[i+1:]...)
+				if j > i {
+					j--
+				}
+				poly = append(poly[:j:j], poly[j+1:]...)
+			} else {
+				poly = append(poly[:i:i], poly[i+1:]...)
+			}
+			changed = true
+			break
+		}
+	}
+	return poly
+}
+
+// polygonArea returns the signed area of the polygon, which is positive for
+// polygons wound clockwise on screen.
+func polygonArea(poly []Point) float64 {
+	var a float64
+	for i, p := range poly {
+		q := poly[(i+1)%len(poly)]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return a / 2
+}
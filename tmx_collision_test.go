@@ -0,0 +1,228 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tile 1 is solid, tile 2 has its top half solid, tile 3 has a triangle and a
+// one-way platform line along its top. The third row holds tile 2 flipped
+// vertically (gid 0x40000002).
+const testCollisionMap = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="4" height="3" tilewidth="16" tileheight="16" nextlayerid="2" nextobjectid="1">
+ <tileset firstgid="1" name="collision" tilewidth="16" tileheight="16" tilecount="4" columns="4">
+  <image source="tilesets/tileset.png" width="64" height="16"/>
+  <tile id="0">
+   <properties>
+    <property name="solid" type="bool" value="true"/>
+   </properties>
+  </tile>
+  <tile id="1">
+   <objectgroup draworder="index">
+    <object id="1" x="0" y="0" width="16" height="8"/>
+   </objectgroup>
+  </tile>
+  <tile id="2">
+   <objectgroup draworder="index">
+    <object id="1" x="0" y="0">
+     <polygon points="0,0 16,0 0,16"/>
+    </object>
+    <object id="2" x="0" y="0">
+     <polyline points="0,0 16,0"/>
+    </object>
+    <object id="3" x="8" y="8">
+     <point/>
+    </object>
+   </objectgroup>
+  </tile>
+ </tileset>
+ <layer id="1" name="Ground" width="4" height="3">
+  <data encoding="csv">
+1,1,2,2,
+1,1,0,3,
+1073741826,1073741826,0,0
+</data>
+ </layer>
+</map>
+`
+
+func TestLayer_Collision(t *testing.T) {
+	m, err := LoadReader(GetAssetsDirectory(), strings.NewReader(testCollisionMap))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c, err := m.Layers[0].Collision()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.ElementsMatch(t, []Rect{
+		// The solid 2x2 block.
+		NewRect(0, 0, 32, 32),
+		// The top halves of the two tiles with id 1.
+		NewRect(32, 0, 32, 8),
+		// The flipped tiles have their bottom half solid.
+		NewRect(0, 40, 32, 8),
+	}, c.Rects)
+	assert.Equal(t, [][]Point{{{X: 48, Y: 16}, {X: 64, Y: 16}, {X: 48, Y: 32}}}, c.Polygons)
+	assert.Equal(t, [][]Point{{{X: 48, Y: 16}, {X: 64, Y: 16}}}, c.Polylines)
+
+	c, err = m.Layers[0].Collision(WithSolidProperty(""))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.ElementsMatch(t, []Rect{NewRect(32, 0, 32, 8), NewRect(0, 40, 32, 8)}, c.Rects)
+}
+
+func TestLayer_CollisionMergedPolygons(t *testing.T) {
+	// Two triangles sharing the right side of the first tile make up a
+	// parallelogram.
+	const tmx = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="2" height="1" tilewidth="16" tileheight="16" nextlayerid="2" nextobjectid="1">
+ <tileset firstgid="1" name="slopes" tilewidth="16" tileheight="16" tilecount="2" columns="2">
+  <image source="tilesets/tileset.png" width="32" height="16"/>
+  <tile id="0">
+   <objectgroup draworder="index">
+    <object id="1" x="0" y="0"><polygon points="16,0 16,16 0,16"/></object>
+   </objectgroup>
+  </tile>
+  <tile id="1">
+   <objectgroup draworder="index">
+    <object id="1" x="0" y="0"><polygon points="0,0 16,0 0,16"/></object>
+   </objectgroup>
+  </tile>
+ </tileset>
+ <layer id="1" name="Ground" width="2" height="1"><data encoding="csv">1,2</data></layer>
+</map>`
+
+	m, err := LoadReader(GetAssetsDirectory(), strings.NewReader(tmx))
+	if !assert.NoError(t, err) {
+		return
+	}
+	c, err := m.Layers[0].Collision()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, c.Polygons, 1) {
+		assert.ElementsMatch(t, []Point{{X: 0, Y: 16}, {X: 16, Y: 0}, {X: 32, Y: 0}, {X: 16, Y: 16}}, c.Polygons[0])
+	}
+}
+
+// testSolidCollisionMap has a solid tile and a tile with a collision rectangle
+// covering its image, in a 32x16 tileset.
+const testSolidCollisionMap = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="%s" renderorder="right-down" width="%d" height="%d" tilewidth="32" tileheight="16" staggeraxis="y" staggerindex="odd" nextlayerid="2" nextobjectid="1">
+ <tileset firstgid="1" name="solid" tilewidth="32" tileheight="16" tilecount="2" columns="2">
+  <image source="tilesets/tileset.png" width="64" height="16"/>
+  <tile id="0">
+   <properties>
+    <property name="solid" type="bool" value="true"/>
+   </properties>
+  </tile>
+  <tile id="1">
+   <objectgroup draworder="index">
+    <object id="1" x="0" y="0" width="32" height="16"/>
+   </objectgroup>
+  </tile>
+ </tileset>
+ <layer id="1" name="Ground" width="%d" height="%d"><data encoding="csv">%s</data></layer>
+</map>`
+
+func TestLayer_CollisionIsometric(t *testing.T) {
+	m, err := LoadReader(GetAssetsDirectory(), strings.NewReader(fmt.Sprintf(testSolidCollisionMap, "isometric", 3, 1, 3, 1, "1,1,2")))
+	if !assert.NoError(t, err) {
+		return
+	}
+	c, err := m.Layers[0].Collision()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Solid cells are squares in pixel coordinates, while the rectangle
+	// covering the image of the third tile is turned into a diamond.
+	assert.Equal(t, []Rect{NewRect(0, 0, 32, 16)}, c.Rects)
+	if assert.Len(t, c.Polygons, 1) {
+		assert.ElementsMatch(t, []Point{{X: 24, Y: 8}, {X: 40, Y: -8}, {X: 56, Y: 8}, {X: 40, Y: 24}}, c.Polygons[0])
+	}
+}
+
+func TestLayer_CollisionStaggered(t *testing.T) {
+	m, err := LoadReader(GetAssetsDirectory(), strings.NewReader(fmt.Sprintf(testSolidCollisionMap, "staggered", 1, 2, 1, 2, "1,1")))
+	if !assert.NoError(t, err) {
+		return
+	}
+	c, err := m.Layers[0].Collision()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The diamonds of the two solid cells share an edge, and make up a
+	// parallelogram.
+	assert.Empty(t, c.Rects)
+	if assert.Len(t, c.Polygons, 1) {
+		assert.ElementsMatch(t, []Point{{X: 0, Y: 8}, {X: 16, Y: 0}, {X: 48, Y: 16}, {X: 32, Y: 24}}, c.Polygons[0])
+	}
+}
+
+func TestLayer_CollisionUnsupportedOrientation(t *testing.T) {
+	m, err := LoadReader(GetAssetsDirectory(), strings.NewReader(strings.Replace(testCollisionMap, `"orthogonal"`, `"cubic"`, 1)))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = m.Layers[0].Collision()
+	assert.ErrorIs(t, err, ErrUnsupportedOrientation)
+}
+
+func TestMergePolygons(t *testing.T) {
+	// A ring of eight unit squares, given in both windings, merges into a
+	// single outline around the hole.
+	var squares [][]Point
+	for y := 0.0; y < 3; y++ {
+		for x := 0.0; x < 3; x++ {
+			if x == 1 && y == 1 {
+				continue
+			}
+			square := []Point{{X: x, Y: y}, {X: x + 1, Y: y}, {X: x + 1, Y: y + 1}, {X: x, Y: y + 1}}
+			if x == 2 {
+				square[1], square[3] = square[3], square[1]
+			}
+			squares = append(squares, square)
+		}
+	}
+
+	merged := mergePolygons(squares)
+	if assert.Len(t, merged, 1) {
+		assert.InDelta(t, 8, polygonArea(merged[0]), 1e-9)
+		for _, p := range []Point{{X: 0.5, Y: 0.5}, {X: 2.5, Y: 1.5}, {X: 1.5, Y: 2.5}} {
+			assert.True(t, polygonContains(merged[0], p.X, p.Y), "%v", p)
+		}
+		assert.False(t, polygonContains(merged[0], 1.5, 1.5))
+	}
+}
@@ -0,0 +1,393 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import "math"
+
+// The map uses three coordinate spaces, named as in Tiled's own MapRenderer:
+//
+//   - tile coordinates: integer (x, y) cell indexes into a tile layer, as used
+//     by Layer.Tiles[y*Width+x];
+//   - pixel coordinates: the unprojected space object positions are stored in
+//     (Object.X/Y). For orthogonal, staggered and hexagonal maps this is the
+//     same as screen space; isometric maps use a square cell of TileHeight
+//     pixels along both axes instead;
+//   - screen coordinates: the projected space the map is drawn in, with (0, 0)
+//     at the top-left corner of the rendered map image.
+//
+// None of the conversions below take layer offsets or parallax into account.
+
+// staggerParams mirrors Tiled's HexagonalRenderer::RenderParams, which both
+// the hexagonal and the staggered orientation are computed from.
+type staggerParams struct {
+	tileWidth, tileHeight    int
+	sideLengthX, sideLengthY int
+	sideOffsetX, sideOffsetY int
+	columnWidth, rowHeight   int
+	staggerX, staggerEven    bool
+}
+
+func (m *Map) staggerParams() staggerParams {
+	p := staggerParams{
+		tileWidth:   m.TileWidth,
+		tileHeight:  m.TileHeight,
+		staggerX:    m.StaggerAxis == AxisX,
+		staggerEven: m.StaggerIndex == StaggerIndexEven,
+	}
+	if m.Orientation == "hexagonal" {
+		if p.staggerX {
+			p.sideLengthX = m.HexSideLength
+		} else {
+			p.sideLengthY = m.HexSideLength
+		}
+	}
+	p.sideOffsetX = (p.tileWidth - p.sideLengthX) / 2
+	p.sideOffsetY = (p.tileHeight - p.sideLengthY) / 2
+	p.columnWidth = p.sideOffsetX + p.sideLengthX
+	p.rowHeight = p.sideOffsetY + p.sideLengthY
+	return p
+}
+
+// doStagger reports whether the given column (for StaggerAxis "x") or row (for
+// StaggerAxis "y") index is one of the ones shifted half a step.
+func (p *staggerParams) doStagger(index int) bool {
+	return (index&1 == 1) != p.staggerEven
+}
+
+func (p *staggerParams) topLeft(x, y int) (int, int) {
+	if p.staggerX {
+		if p.doStagger(x) {
+			return x - 1, y
+		}
+		return x - 1, y - 1
+	}
+	if p.doStagger(y) {
+		return x, y - 1
+	}
+	return x - 1, y - 1
+}
+
+func (p *staggerParams) topRight(x, y int) (int, int) {
+	if p.staggerX {
+		if p.doStagger(x) {
+			return x + 1, y
+		}
+		return x + 1, y - 1
+	}
+	if p.doStagger(y) {
+		return x + 1, y - 1
+	}
+	return x, y - 1
+}
+
+func (p *staggerParams) bottomLeft(x, y int) (int, int) {
+	if p.staggerX {
+		if p.doStagger(x) {
+			return x - 1, y + 1
+		}
+		return x - 1, y
+	}
+	if p.doStagger(y) {
+		return x, y + 1
+	}
+	return x - 1, y + 1
+}
+
+func (p *staggerParams) bottomRight(x, y int) (int, int) {
+	if p.staggerX {
+		if p.doStagger(x) {
+			return x + 1, y + 1
+		}
+		return x + 1, y
+	}
+	if p.doStagger(y) {
+		return x + 1, y + 1
+	}
+	return x, y + 1
+}
+
+// tileToScreen returns the top-left corner of the bounding box of tile (x, y),
+// as in Tiled's HexagonalRenderer::tileToScreenCoords.
+func (p *staggerParams) tileToScreen(x, y int) (float64, float64) {
+	var px, py int
+	if p.staggerX {
+		px = x * p.columnWidth
+		py = y * (p.tileHeight + p.sideLengthY)
+		if p.doStagger(x) {
+			py += p.rowHeight
+		}
+	} else {
+		px = x * (p.tileWidth + p.sideLengthX)
+		if p.doStagger(y) {
+			px += p.columnWidth
+		}
+		py = y * p.rowHeight
+	}
+	return float64(px), float64(py)
+}
+
+// hexScreenToTile picks the hexagon containing the given screen position,
+// based on Tiled's HexagonalRenderer::screenToTileCoords.
+func (p *staggerParams) hexScreenToTile(x, y float64) (int, int) {
+	if p.staggerX {
+		if p.staggerEven {
+			x -= float64(p.tileWidth)
+		} else {
+			x -= float64(p.sideOffsetX)
+		}
+	} else {
+		if p.staggerEven {
+			y -= float64(p.tileHeight)
+		} else {
+			y -= float64(p.sideOffsetY)
+		}
+	}
+
+	// Start with the coordinates of a grid-aligned tile, and the position
+	// relative to its base square.
+	cellW := float64(p.columnWidth * 2)
+	cellH := float64(p.rowHeight * 2)
+	refX := int(math.Floor(x / cellW))
+	refY := int(math.Floor(y / cellH))
+	relX := x - float64(refX)*cellW
+	relY := y - float64(refY)*cellH
+
+	if p.staggerX {
+		refX *= 2
+		if p.staggerEven {
+			refX++
+		}
+	} else {
+		refY *= 2
+		if p.staggerEven {
+			refY++
+		}
+	}
+
+	// Of the four tiles overlapping the base square, pick the one whose
+	// hexagon contains the position. Tiled itself only picks the one with the
+	// nearest center, which is exact for regular hexagons only; that is kept
+	// as the fallback for positions exactly on an edge.
+	var centers [4][2]float64
+	var offsets [4][2]int
+	cw, rh := float64(p.columnWidth), float64(p.rowHeight)
+	if p.staggerX {
+		left := float64(p.sideLengthX / 2)
+		centerX := left + cw
+		centerY := float64(p.tileHeight / 2)
+		centers = [4][2]float64{{left, centerY}, {centerX, centerY - rh}, {centerX, centerY + rh}, {centerX + cw, centerY}}
+		offsets = [4][2]int{{0, 0}, {1, -1}, {1, 0}, {2, 0}}
+	} else {
+		top := float64(p.sideLengthY / 2)
+		centerX := float64(p.tileWidth / 2)
+		centerY := top + rh
+		centers = [4][2]float64{{centerX, top}, {centerX - cw, centerY}, {centerX + cw, centerY}, {centerX, centerY + rh}}
+		offsets = [4][2]int{{0, 0}, {-1, 1}, {0, 1}, {0, 2}}
+	}
+
+	nearest := 0
+	minDist := math.MaxFloat64
+	for i, c := range centers {
+		dx, dy := relX-c[0], relY-c[1]
+		if p.hexContains(dx, dy) {
+			return refX + offsets[i][0], refY + offsets[i][1]
+		}
+		if d := dx*dx + dy*dy; d < minDist {
+			minDist = d
+			nearest = i
+		}
+	}
+
+	return refX + offsets[nearest][0], refY + offsets[nearest][1]
+}
+
+// hexContains reports whether the position (dx, dy), relative to the center
+// of a hexagon tile, lies strictly inside of it.
+func (p *staggerParams) hexContains(dx, dy float64) bool {
+	// Fold into the bottom-right quadrant; the hexagon is symmetric along
+	// both axes. What remains is a rectangle with one corner cut off.
+	dx, dy = math.Abs(dx), math.Abs(dy)
+	halfW, halfH := float64(p.tileWidth)/2, float64(p.tileHeight)/2
+	if dx >= halfW || dy >= halfH {
+		return false
+	}
+
+	// The cut runs from the end of the flat side to the middle of the
+	// adjacent side of the bounding box.
+	var ax, ay, bx, by float64
+	if p.staggerX {
+		ax, ay = float64(p.sideLengthX)/2, halfH
+		bx, by = halfW, 0
+	} else {
+		ax, ay = 0, halfH
+		bx, by = halfW, float64(p.sideLengthY)/2
+	}
+	return (bx-ax)*(dy-ay)-(by-ay)*(dx-ax) < 0
+}
+
+// staggeredScreenToTile picks the diamond containing the given screen
+// position, as in Tiled's StaggeredRenderer::screenToTileCoords.
+func (p *staggerParams) staggeredScreenToTile(x, y float64) (int, int) {
+	if p.staggerEven {
+		if p.staggerX {
+			x -= float64(p.sideOffsetX)
+		} else {
+			y -= float64(p.sideOffsetY)
+		}
+	}
+
+	tw, th := float64(p.tileWidth), float64(p.tileHeight)
+	refX := int(math.Floor(x / tw))
+	refY := int(math.Floor(y / th))
+	relX := x - float64(refX)*tw
+	relY := y - float64(refY)*th
+
+	if p.staggerX {
+		refX *= 2
+		if p.staggerEven {
+			refX++
+		}
+	} else {
+		refY *= 2
+		if p.staggerEven {
+			refY++
+		}
+	}
+
+	// The reference tile's diamond touches the middle of each side of its
+	// bounding box; the four corners outside of it belong to its neighbours.
+	yPos := relX * (th / tw)
+	sideOffsetY := float64(p.sideOffsetY)
+	switch {
+	case sideOffsetY-yPos > relY:
+		return p.topLeft(refX, refY)
+	case -sideOffsetY+yPos > relY:
+		return p.topRight(refX, refY)
+	case sideOffsetY+yPos < relY:
+		return p.bottomLeft(refX, refY)
+	case sideOffsetY*3-yPos < relY:
+		return p.bottomRight(refX, refY)
+	}
+	return refX, refY
+}
+
+// isometricOriginX is the screen x coordinate of the top corner of tile (0, 0)
+// on an isometric map.
+func (m *Map) isometricOriginX() float64 {
+	return float64(m.Height*m.TileWidth) / 2
+}
+
+// PixelToScreen projects a position from pixel coordinates (the space object
+// positions are stored in) into screen coordinates.
+func (m *Map) PixelToScreen(x, y float64) (float64, float64) {
+	if m.Orientation != "isometric" {
+		return x, y
+	}
+
+	tw, th := float64(m.TileWidth), float64(m.TileHeight)
+	tileX := x / th
+	tileY := y / th
+
+	return (tileX-tileY)*tw/2 + m.isometricOriginX(), (tileX + tileY) * th / 2
+}
+
+// ScreenToPixel is the inverse of PixelToScreen.
+func (m *Map) ScreenToPixel(x, y float64) (float64, float64) {
+	if m.Orientation != "isometric" {
+		return x, y
+	}
+
+	tw, th := float64(m.TileWidth), float64(m.TileHeight)
+	x -= m.isometricOriginX()
+	tileY := y / th
+	tileX := x / tw
+
+	return (tileY + tileX) * th, (tileY - tileX) * th
+}
+
+// TileToPixel returns the pixel coordinates of the top-left corner of tile
+// (x, y). On staggered and hexagonal maps this is the top-left corner of the
+// tile's bounding box.
+func (m *Map) TileToPixel(x, y int) (float64, float64) {
+	switch m.Orientation {
+	case "isometric":
+		return float64(x * m.TileHeight), float64(y * m.TileHeight)
+	case "staggered", "hexagonal":
+		p := m.staggerParams()
+		return p.tileToScreen(x, y)
+	default:
+		return float64(x * m.TileWidth), float64(y * m.TileHeight)
+	}
+}
+
+// PixelToTile returns the tile containing the given pixel position. The result
+// may lie outside of the map when the position does.
+func (m *Map) PixelToTile(x, y float64) (int, int) {
+	switch m.Orientation {
+	case "isometric":
+		th := float64(m.TileHeight)
+		return int(math.Floor(x / th)), int(math.Floor(y / th))
+	case "staggered", "hexagonal":
+		return m.ScreenToTile(x, y)
+	default:
+		return int(math.Floor(x / float64(m.TileWidth))), int(math.Floor(y / float64(m.TileHeight)))
+	}
+}
+
+// TileToScreen returns the screen coordinates of the top-left corner of the
+// bounding box of tile (x, y), i.e. where a tile image exactly the size of a
+// map tile is drawn. For isometric maps this is half a tile to the left of the
+// top corner of the tile's diamond.
+func (m *Map) TileToScreen(x, y int) (float64, float64) {
+	switch m.Orientation {
+	case "isometric":
+		sx, sy := m.PixelToScreen(float64(x*m.TileHeight), float64(y*m.TileHeight))
+		return sx - float64(m.TileWidth)/2, sy
+	case "staggered", "hexagonal":
+		p := m.staggerParams()
+		return p.tileToScreen(x, y)
+	default:
+		return float64(x * m.TileWidth), float64(y * m.TileHeight)
+	}
+}
+
+// ScreenToTile returns the tile drawn at the given screen position, which makes
+// it suitable for picking the tile under a cursor. On isometric, staggered and
+// hexagonal maps this is the tile whose diamond or hexagon contains the
+// position, not the one whose bounding box does. The result may lie outside
+// of the map when the position does.
+func (m *Map) ScreenToTile(x, y float64) (int, int) {
+	switch m.Orientation {
+	case "isometric":
+		px, py := m.ScreenToPixel(x, y)
+		return m.PixelToTile(px, py)
+	case "staggered":
+		p := m.staggerParams()
+		return p.staggeredScreenToTile(x, y)
+	case "hexagonal":
+		p := m.staggerParams()
+		return p.hexScreenToTile(x, y)
+	default:
+		return m.PixelToTile(x, y)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap_OrthogonalCoords(t *testing.T) {
+	m := &Map{Orientation: "orthogonal", Width: 10, Height: 8, TileWidth: 32, TileHeight: 16}
+
+	sx, sy := m.TileToScreen(3, 2)
+	assert.Equal(t, 96.0, sx)
+	assert.Equal(t, 32.0, sy)
+
+	x, y := m.ScreenToTile(127.5, 47.9)
+	assert.Equal(t, 3, x)
+	assert.Equal(t, 2, y)
+
+	x, y = m.ScreenToTile(-1, -1)
+	assert.Equal(t, -1, x)
+	assert.Equal(t, -1, y)
+
+	px, py := m.PixelToScreen(12, 34)
+	assert.Equal(t, 12.0, px)
+	assert.Equal(t, 34.0, py)
+}
+
+// TestMap_IsometricCoords uses the same map and expected values as the render
+// package's IsometricRendererEngine tests, so both stay in agreement.
+func TestMap_IsometricCoords(t *testing.T) {
+	m := &Map{Orientation: "isometric", Width: 10, Height: 8, TileWidth: 64, TileHeight: 32}
+
+	sx, sy := m.PixelToScreen(128, 64)
+	assert.Equal(t, 320.0, sx)
+	assert.Equal(t, 96.0, sy)
+
+	px, py := m.ScreenToPixel(320, 96)
+	assert.Equal(t, 128.0, px)
+	assert.Equal(t, 64.0, py)
+
+	sx, sy = m.TileToScreen(3, 2)
+	assert.Equal(t, 256.0, sx)
+	assert.Equal(t, 80.0, sy)
+
+	px, py = m.TileToPixel(3, 2)
+	assert.Equal(t, 96.0, px)
+	assert.Equal(t, 64.0, py)
+
+	x, y := m.PixelToTile(100, 70)
+	assert.Equal(t, 3, x)
+	assert.Equal(t, 2, y)
+
+	// The left corner of the diamond of tile (3, 2) belongs to it, while the
+	// top-left corner of its bounding box belongs to tile (2, 2).
+	x, y = m.ScreenToTile(257, 96)
+	assert.Equal(t, 3, x)
+	assert.Equal(t, 2, y)
+	x, y = m.ScreenToTile(257, 81)
+	assert.Equal(t, 2, x)
+	assert.Equal(t, 2, y)
+}
+
+// TestMap_StaggeredScreenToTile verifies that picking the center of every
+// tile, or a position near any of its sides, returns that tile, for both
+// stagger axes and indexes of both the staggered and the hexagonal
+// orientation. The hexagons used are deliberately not regular, which picking
+// purely by the nearest tile center gets wrong.
+func TestMap_StaggeredScreenToTile(t *testing.T) {
+	maps := []*Map{
+		{Orientation: "staggered", Width: 7, Height: 9, TileWidth: 64, TileHeight: 32, StaggerAxis: AxisY, StaggerIndex: StaggerIndexOdd},
+		{Orientation: "staggered", Width: 7, Height: 9, TileWidth: 64, TileHeight: 32, StaggerAxis: AxisY, StaggerIndex: StaggerIndexEven},
+		{Orientation: "staggered", Width: 7, Height: 9, TileWidth: 64, TileHeight: 32, StaggerAxis: AxisX, StaggerIndex: StaggerIndexOdd},
+		{Orientation: "staggered", Width: 7, Height: 9, TileWidth: 64, TileHeight: 32, StaggerAxis: AxisX, StaggerIndex: StaggerIndexEven},
+		{Orientation: "hexagonal", Width: 7, Height: 9, TileWidth: 40, TileHeight: 30, HexSideLength: 10, StaggerAxis: AxisY, StaggerIndex: StaggerIndexOdd},
+		{Orientation: "hexagonal", Width: 7, Height: 9, TileWidth: 40, TileHeight: 30, HexSideLength: 10, StaggerAxis: AxisY, StaggerIndex: StaggerIndexEven},
+		{Orientation: "hexagonal", Width: 7, Height: 9, TileWidth: 40, TileHeight: 20, HexSideLength: 8, StaggerAxis: AxisX, StaggerIndex: StaggerIndexOdd},
+		{Orientation: "hexagonal", Width: 7, Height: 9, TileWidth: 40, TileHeight: 20, HexSideLength: 8, StaggerAxis: AxisX, StaggerIndex: StaggerIndexEven},
+	}
+
+	// Offsets from the tile center, as fractions of the tile size.
+	offsets := [][2]float64{
+		{0, 0},
+		{0.45, 0}, {-0.45, 0}, {0, 0.45}, {0, -0.45},
+		{0.2, 0.2}, {-0.2, 0.2}, {0.2, -0.2}, {-0.2, -0.2},
+	}
+
+	for _, m := range maps {
+		t.Run(fmt.Sprintf("%s/%s/%s", m.Orientation, m.StaggerAxis, m.StaggerIndex), func(t *testing.T) {
+			for y := 0; y < m.Height; y++ {
+				for x := 0; x < m.Width; x++ {
+					sx, sy := m.TileToScreen(x, y)
+					for _, o := range offsets {
+						px := sx + (0.5+o[0])*float64(m.TileWidth)
+						py := sy + (0.5+o[1])*float64(m.TileHeight)
+
+						gx, gy := m.ScreenToTile(px, py)
+						assert.Equal(t, [2]int{x, y}, [2]int{gx, gy}, "ScreenToTile(%v, %v)", px, py)
+
+						gx, gy = m.PixelToTile(px, py)
+						assert.Equal(t, [2]int{x, y}, [2]int{gx, gy}, "PixelToTile(%v, %v)", px, py)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestMap_HexagonalTileToScreen uses the same map and expected values as the
+// render package's flat-top HexagonalRendererEngine test.
+func TestMap_HexagonalTileToScreen(t *testing.T) {
+	m := &Map{
+		Orientation: "hexagonal", Width: 6, Height: 5, TileWidth: 40, TileHeight: 20, HexSideLength: 8,
+		StaggerAxis: AxisX, StaggerIndex: StaggerIndexOdd,
+	}
+
+	tests := []struct {
+		x, y   int
+		sx, sy float64
+	}{
+		{0, 0, 0, 0},
+		{1, 0, 24, 10},
+		{2, 0, 48, 0},
+		{1, 3, 24, 70},
+	}
+
+	for _, tt := range tests {
+		sx, sy := m.TileToScreen(tt.x, tt.y)
+		assert.Equal(t, tt.sx, sx, "TileToScreen(%d, %d)", tt.x, tt.y)
+		assert.Equal(t, tt.sy, sy, "TileToScreen(%d, %d)", tt.x, tt.y)
+	}
+}
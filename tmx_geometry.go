@@ -0,0 +1,274 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import "math"
+
+// Rect is an axis-aligned rectangle in pixel coordinates. It contains the
+// points with Min.X <= X <= Max.X and Min.Y <= Y <= Max.Y.
+type Rect struct {
+	Min, Max Point
+}
+
+// NewRect returns the rectangle with top-left corner (x, y) and the given size.
+func NewRect(x, y, width, height float64) Rect {
+	return Rect{Min: Point{X: x, Y: y}, Max: Point{X: x + width, Y: y + height}}
+}
+
+// Dx returns r's width.
+func (r Rect) Dx() float64 {
+	return r.Max.X - r.Min.X
+}
+
+// Dy returns r's height.
+func (r Rect) Dy() float64 {
+	return r.Max.Y - r.Min.Y
+}
+
+// Contains reports whether the point (x, y) lies within r.
+func (r Rect) Contains(x, y float64) bool {
+	return x >= r.Min.X && x <= r.Max.X && y >= r.Min.Y && y <= r.Max.Y
+}
+
+// Intersects reports whether r and s have at least one point in common.
+func (r Rect) Intersects(s Rect) bool {
+	return r.Min.X <= s.Max.X && s.Min.X <= r.Max.X && r.Min.Y <= s.Max.Y && s.Min.Y <= r.Max.Y
+}
+
+// Union returns the smallest rectangle that contains both r and s.
+func (r Rect) Union(s Rect) Rect {
+	return Rect{
+		Min: Point{X: math.Min(r.Min.X, s.Min.X), Y: math.Min(r.Min.Y, s.Min.Y)},
+		Max: Point{X: math.Max(r.Max.X, s.Max.X), Y: math.Max(r.Max.Y, s.Max.Y)},
+	}
+}
+
+// boundsOf returns the smallest rectangle containing all of points, which
+// must not be empty.
+func boundsOf(points []Point) Rect {
+	r := Rect{Min: points[0], Max: points[0]}
+	for _, p := range points[1:] {
+		r.Min.X = math.Min(r.Min.X, p.X)
+		r.Min.Y = math.Min(r.Min.Y, p.Y)
+		r.Max.X = math.Max(r.Max.X, p.X)
+		r.Max.Y = math.Max(r.Max.Y, p.Y)
+	}
+	return r
+}
+
+// rotateAround rotates p clockwise (in y-down screen space) by the angle
+// given as its sine and cosine, around the origin (ox, oy).
+func rotateAround(p Point, ox, oy, sin, cos float64) Point {
+	x, y := p.X-ox, p.Y-oy
+	return Point{X: ox + x*cos - y*sin, Y: oy + x*sin + y*cos}
+}
+
+// polygonContains reports whether (x, y) lies inside the closed polygon,
+// using the even-odd rule.
+func polygonContains(poly []Point, x, y float64) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// cross returns the z component of the cross product of (b-a) and (c-a).
+func cross(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// segmentsIntersect reports whether the segments a-b and c-d touch.
+func segmentsIntersect(a, b, c, d Point) bool {
+	d1, d2 := cross(c, d, a), cross(c, d, b)
+	d3, d4 := cross(a, b, c), cross(a, b, d)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	onSegment := func(p, q, r Point) bool {
+		return math.Min(p.X, q.X) <= r.X && r.X <= math.Max(p.X, q.X) &&
+			math.Min(p.Y, q.Y) <= r.Y && r.Y <= math.Max(p.Y, q.Y)
+	}
+	return (d1 == 0 && onSegment(c, d, a)) || (d2 == 0 && onSegment(c, d, b)) ||
+		(d3 == 0 && onSegment(a, b, c)) || (d4 == 0 && onSegment(a, b, d))
+}
+
+// segmentDistanceSq returns the squared distance from p to the segment a-b.
+func segmentDistanceSq(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/l))
+	}
+	ex, ey := a.X+t*dx-p.X, a.Y+t*dy-p.Y
+	return ex*ex + ey*ey
+}
+
+// forEachSegment calls fn for each edge of the polyline, and for the closing
+// edge as well if closed is set.
+func forEachSegment(points []Point, closed bool, fn func(a, b Point) bool) bool {
+	for i := 1; i < len(points); i++ {
+		if fn(points[i-1], points[i]) {
+			return true
+		}
+	}
+	if closed && len(points) > 2 {
+		return fn(points[len(points)-1], points[0])
+	}
+	return false
+}
+
+// polyIntersectsRect reports whether the polygon (if closed) or polyline
+// shares at least one point with r.
+func polyIntersectsRect(points []Point, closed bool, r Rect) bool {
+	for _, p := range points {
+		if r.Contains(p.X, p.Y) {
+			return true
+		}
+	}
+	corners := []Point{r.Min, {X: r.Max.X, Y: r.Min.Y}, r.Max, {X: r.Min.X, Y: r.Max.Y}}
+	if closed && len(points) > 2 && polygonContains(points, corners[0].X, corners[0].Y) {
+		return true
+	}
+	return forEachSegment(points, closed, func(a, b Point) bool {
+		return forEachSegment(corners, true, func(c, d Point) bool {
+			return segmentsIntersect(a, b, c, d)
+		})
+	})
+}
+
+// polyIntersectsCircle reports whether the polygon (if closed) or polyline
+// shares at least one point with the circle around (x, y).
+func polyIntersectsCircle(points []Point, closed bool, x, y, radius float64) bool {
+	if closed && len(points) > 2 && polygonContains(points, x, y) {
+		return true
+	}
+	c := Point{X: x, Y: y}
+	r2 := radius * radius
+	if len(points) == 1 {
+		return segmentDistanceSq(c, points[0], points[0]) <= r2
+	}
+	return forEachSegment(points, closed, func(a, b Point) bool {
+		return segmentDistanceSq(c, a, b) <= r2
+	})
+}
+
+// ellipseSpace returns a function mapping pixel coordinates into the space in
+// which the ellipse shape s is the unit circle around the origin.
+func ellipseSpace(s Shape) func(p Point) Point {
+	rx, ry := s.Box.Dx()/2, s.Box.Dy()/2
+	cx, cy := s.Origin.X+s.Box.Min.X+rx, s.Origin.Y+s.Box.Min.Y+ry
+	sin, cos := math.Sincos(-s.Rotation * math.Pi / 180)
+	return func(p Point) Point {
+		p = rotateAround(p, s.Origin.X, s.Origin.Y, sin, cos)
+		return Point{X: (p.X - cx) / rx, Y: (p.Y - cy) / ry}
+	}
+}
+
+// ellipseIntersectsRect reports whether the ellipse shape s, which must have a
+// non-zero size, shares at least one point with r.
+func ellipseIntersectsRect(s Shape, r Rect) bool {
+	// In the ellipse's unit space, r becomes a parallelogram, which touches
+	// the unit circle if it contains its center or has an edge close enough.
+	toUnit := ellipseSpace(s)
+	corners := []Point{
+		toUnit(r.Min), toUnit(Point{X: r.Max.X, Y: r.Min.Y}),
+		toUnit(r.Max), toUnit(Point{X: r.Min.X, Y: r.Max.Y}),
+	}
+	if polygonContains(corners, 0, 0) {
+		return true
+	}
+	return forEachSegment(corners, true, func(a, b Point) bool {
+		return segmentDistanceSq(Point{}, a, b) <= 1
+	})
+}
+
+// ellipseDistance returns the distance from (x, y) to the ellipse shape s,
+// which must have a non-zero size, or 0 if the point lies within it.
+func ellipseDistance(s Shape, x, y float64) float64 {
+	if s.Contains(x, y) {
+		return 0
+	}
+	// Distances don't change by rotating the point back into the unrotated
+	// space of the ellipse, where it's mirrored into the first quadrant.
+	rx, ry := s.Box.Dx()/2, s.Box.Dy()/2
+	sin, cos := math.Sincos(-s.Rotation * math.Pi / 180)
+	p := rotateAround(Point{X: x, Y: y}, s.Origin.X, s.Origin.Y, sin, cos)
+	px := math.Abs(p.X - s.Origin.X - s.Box.Min.X - rx)
+	py := math.Abs(p.Y - s.Origin.Y - s.Box.Min.Y - ry)
+	if rx < ry {
+		rx, ry, px, py = ry, rx, py, px
+	}
+	return pointEllipseDistance(rx, ry, px, py)
+}
+
+// pointEllipseDistance returns the distance from the point (x, y), with x and
+// y non-negative, to the axis-aligned ellipse around the origin with semi-axes
+// a >= b > 0. The closest point on the ellipse is found by bisection, as
+// described by David Eberly in "Distance from a Point to an Ellipse, an
+// Ellipsoid, or a Hyperellipsoid".
+func pointEllipseDistance(a, b, x, y float64) float64 {
+	if y == 0 {
+		// On the major axis, the closest point is either its end or the one
+		// where the normal of the ellipse passes through (x, 0).
+		if n, d := a*x, a*a-b*b; n < d {
+			t := n / d
+			return math.Hypot(a*t-x, b*math.Sqrt(1-t*t))
+		}
+		return math.Abs(x - a)
+	}
+	if x == 0 {
+		return math.Abs(y - b)
+	}
+
+	z0, z1 := x/a, y/b
+	g := z0*z0 + z1*z1 - 1
+	if g == 0 {
+		return 0
+	}
+	r0 := (a / b) * (a / b)
+	n0 := r0 * z0
+	s0, s1 := z1-1, 0.0
+	if g > 0 {
+		s1 = math.Hypot(n0, z1) - 1
+	}
+	var s float64
+	for i := 0; i < 200; i++ {
+		s = (s0 + s1) / 2
+		if s == s0 || s == s1 {
+			break
+		}
+		t0, t1 := n0/(s+r0), z1/(s+1)
+		if g = t0*t0 + t1*t1 - 1; g > 0 {
+			s0 = s
+		} else if g < 0 {
+			s1 = s
+		} else {
+			break
+		}
+	}
+	return math.Hypot(r0*x/(s+r0)-x, y/(s+1)-y)
+}
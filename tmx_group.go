@@ -44,6 +44,9 @@ type Group struct {
 	Opacity float32 `xml:"opacity,attr"`
 	// Whether the layer is shown (1) or hidden (0). Defaults to 1.
 	Visible bool `xml:"visible,attr"`
+	// A tint color that is multiplied with any graphics drawn by the layers
+	// in this group. (since 1.9)
+	TintColor *HexColor `xml:"tintcolor,attr"`
 	// The parallax x factor of the layer 0 - 1.0
 	ParallaxX float32 `xml:"parallaxx,attr"`
 	// The parallax y factor of the layer 0 - 1.0
@@ -63,24 +66,28 @@ type Group struct {
 	ImageLayers []*ImageLayer `xml:"imagelayer"`
 	// Group layers
 	Groups []*Group `xml:"group"`
+	// Position of the group in the map file
+	order int64
 }
 
 // UnmarshalXML decodes a single XML element beginning with the given start element.
 func (g *Group) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	item := aliasGroup{}
 	item.SetDefaults()
+	order := d.InputOffset()
 
 	if err := d.DecodeElement(&item, &start); err != nil {
 		return err
 	}
 
 	*g = (Group)(item)
+	g.order = order
 
 	return nil
 }
 
 // DecodeGroup decodes Group data. This includes all subgroups and the Layer
-// data for each.
+// and ObjectGroup data for each.
 func (g *Group) DecodeGroup(m *Map) error {
 	for i := 0; i < len(g.Groups); i++ {
 		g := g.Groups[i]
@@ -96,5 +103,11 @@ func (g *Group) DecodeGroup(m *Map) error {
 		}
 	}
 
+	for _, og := range g.ObjectGroups {
+		if err := og.DecodeObjectGroup(m); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
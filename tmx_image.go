@@ -52,6 +52,8 @@ type ImageLayer struct {
 	Properties Properties `xml:"properties>property"`
 	// The group image
 	Image *Image `xml:"image"`
+	// A tint color that is multiplied with the image drawn by this layer. (since 1.9)
+	TintColor *HexColor `xml:"tintcolor,attr"`
 	// The parallax x factor of the layer 0 - 1.0
 	ParallaxX float32 `xml:"parallaxx,attr"`
 	// The parallax y factor of the layer 0 - 1.0
@@ -65,18 +67,22 @@ type ImageLayer struct {
 	// color burn, hard light, soft light, difference, exclusion, hue, saturation,
 	// color and luminosity. (since 1.12, defaults to normal)
 	Mode string `xml:"mode,attr"`
+	// Position of the image layer in the map file
+	order int64
 }
 
 // UnmarshalXML decodes a single XML element beginning with the given start element.
 func (l *ImageLayer) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	item := aliasImageLayer{}
 	item.SetDefaults()
+	order := d.InputOffset()
 
 	if err := d.DecodeElement(&item, &start); err != nil {
 		return err
 	}
 
 	*l = (ImageLayer)(item)
+	l.order = order
 
 	return nil
 }
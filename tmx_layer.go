@@ -87,6 +87,8 @@ type Layer struct {
 	OffsetX float64 `xml:"offsetx,attr"`
 	// Rendering offset for this layer in pixels. Defaults to 0. (since 0.14)
 	OffsetY float64 `xml:"offsety,attr"`
+	// A tint color that is multiplied with any tiles drawn by this layer. (since 1.9)
+	TintColor *HexColor `xml:"tintcolor,attr"`
 	// The parallax x factor of the layer 0 - 1.0
 	ParallaxX float32 `xml:"parallaxx,attr"`
 	// The parallax y factor of the layer 0 - 1.0
@@ -104,6 +106,8 @@ type Layer struct {
 	data *Data
 	// Set when all entries of the layer are NilTile
 	empty bool
+	// Position of the layer in the map file
+	order int64
 }
 
 // IsEmpty checks if layer has tiles other than nil
@@ -246,6 +250,7 @@ func (l *Layer) DecodeLayer(m *Map) error {
 func (l *Layer) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	item := aliasLayer{}
 	item.SetDefaults()
+	order := d.InputOffset()
 
 	if err := d.DecodeElement(&item, &start); err != nil {
 		return err
@@ -253,6 +258,7 @@ func (l *Layer) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 
 	*l = (Layer)(item.internalLayer)
 	l.data = item.Data
+	l.order = order
 
 	return nil
 }
@@ -0,0 +1,124 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import (
+	"math"
+	"sort"
+)
+
+// LayerNode is a layer of any kind in the layer tree of a map or group.
+// Exactly one of its fields is set.
+type LayerNode struct {
+	Layer       *Layer
+	ObjectGroup *ObjectGroup
+	ImageLayer  *ImageLayer
+	Group       *Group
+}
+
+// Visible reports whether the layer is shown, not taking the groups it is in
+// into account.
+func (n LayerNode) Visible() bool {
+	switch {
+	case n.Layer != nil:
+		return n.Layer.Visible
+	case n.ObjectGroup != nil:
+		return n.ObjectGroup.Visible
+	case n.ImageLayer != nil:
+		return n.ImageLayer.Visible
+	case n.Group != nil:
+		return n.Group.Visible
+	}
+	return false
+}
+
+// TintColor returns the tint color of the layer, or nil if it has none. It
+// doesn't include the tint colors of the groups the layer is in.
+func (n LayerNode) TintColor() *HexColor {
+	switch {
+	case n.Layer != nil:
+		return n.Layer.TintColor
+	case n.ObjectGroup != nil:
+		return n.ObjectGroup.TintColor
+	case n.ImageLayer != nil:
+		return n.ImageLayer.TintColor
+	case n.Group != nil:
+		return n.Group.TintColor
+	}
+	return nil
+}
+
+func (n LayerNode) order() int64 {
+	var order int64
+	switch {
+	case n.Layer != nil:
+		order = n.Layer.order
+	case n.ObjectGroup != nil:
+		order = n.ObjectGroup.order
+	case n.ImageLayer != nil:
+		order = n.ImageLayer.order
+	case n.Group != nil:
+		order = n.Group.order
+	}
+	// Layers that weren't loaded from a file come after those that were.
+	if order == 0 {
+		return math.MaxInt64
+	}
+	return order
+}
+
+// orderedLayers returns the given layers in the order they appear in the
+// file. Layers that weren't loaded from a file keep the order of their kinds:
+// tile layers, object groups, image layers and groups.
+func orderedLayers(layers []*Layer, objectGroups []*ObjectGroup, imageLayers []*ImageLayer, groups []*Group) []LayerNode {
+	nodes := make([]LayerNode, 0, len(layers)+len(objectGroups)+len(imageLayers)+len(groups))
+	for _, l := range layers {
+		nodes = append(nodes, LayerNode{Layer: l})
+	}
+	for _, g := range objectGroups {
+		nodes = append(nodes, LayerNode{ObjectGroup: g})
+	}
+	for _, l := range imageLayers {
+		nodes = append(nodes, LayerNode{ImageLayer: l})
+	}
+	for _, g := range groups {
+		nodes = append(nodes, LayerNode{Group: g})
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].order() < nodes[j].order()
+	})
+	return nodes
+}
+
+// OrderedLayers returns the top-level layers of the map, of all kinds, in the
+// order they appear in the map file, which is the order Tiled renders them
+// in, from bottom to top.
+func (m *Map) OrderedLayers() []LayerNode {
+	return orderedLayers(m.Layers, m.ObjectGroups, m.ImageLayers, m.Groups)
+}
+
+// OrderedLayers returns the layers of the group, of all kinds, in the order
+// they appear in the map file, from bottom to top.
+func (g *Group) OrderedLayers() []LayerNode {
+	return orderedLayers(g.Layers, g.ObjectGroups, g.ImageLayers, g.Groups)
+}
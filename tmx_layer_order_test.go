@@ -0,0 +1,99 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap_OrderedLayers(t *testing.T) {
+	m, err := LoadReader(GetAssetsDirectory(), strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" width="1" height="1" tilewidth="8" tileheight="8">
+ <imagelayer id="1" name="background"/>
+ <objectgroup id="2" name="spawns"/>
+ <layer id="3" name="ground" width="1" height="1"><data encoding="csv">0</data></layer>
+ <group id="4" name="decor" visible="0">
+  <objectgroup id="5" name="signs"/>
+  <layer id="6" name="walls" width="1" height="1"><data encoding="csv">0</data></layer>
+ </group>
+ <layer id="7" name="roof" width="1" height="1"><data encoding="csv">0</data></layer>
+</map>`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	names := func(nodes []LayerNode) []string {
+		var l []string
+		for _, n := range nodes {
+			switch {
+			case n.Layer != nil:
+				l = append(l, n.Layer.Name)
+			case n.ObjectGroup != nil:
+				l = append(l, n.ObjectGroup.Name)
+			case n.ImageLayer != nil:
+				l = append(l, n.ImageLayer.Name)
+			case n.Group != nil:
+				l = append(l, n.Group.Name)
+			}
+		}
+		return l
+	}
+
+	nodes := m.OrderedLayers()
+	assert.Equal(t, []string{"background", "spawns", "ground", "decor", "roof"}, names(nodes))
+	assert.False(t, nodes[3].Visible())
+	assert.True(t, nodes[4].Visible())
+	assert.Equal(t, []string{"signs", "walls"}, names(m.Groups[0].OrderedLayers()))
+
+	// Layers added in code come last.
+	m.Layers = append(m.Layers, &Layer{Name: "overlay"})
+	assert.Equal(t, []string{"background", "spawns", "ground", "decor", "roof", "overlay"}, names(m.OrderedLayers()))
+}
+
+func TestLayerNode_TintColor(t *testing.T) {
+	m, err := LoadReader(GetAssetsDirectory(), strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" width="1" height="1" tilewidth="8" tileheight="8">
+ <layer id="1" name="ground" width="1" height="1" tintcolor="#ff8000"><data encoding="csv">0</data></layer>
+ <objectgroup id="2" name="spawns" tintcolor="#80ff0000"/>
+ <imagelayer id="3" name="background" tintcolor="#0000ff"/>
+ <group id="4" name="decor" tintcolor="#00ff00">
+  <layer id="5" name="walls" width="1" height="1"><data encoding="csv">0</data></layer>
+ </group>
+</map>`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	nodes := m.OrderedLayers()
+	assert.Equal(t, NewHexColor(255, 128, 0, 255), m.Layers[0].TintColor)
+	assert.Equal(t, NewHexColor(255, 0, 0, 128), m.ObjectGroups[0].TintColor)
+	assert.Equal(t, NewHexColor(0, 0, 255, 255), m.ImageLayers[0].TintColor)
+	assert.Equal(t, NewHexColor(0, 255, 0, 255), m.Groups[0].TintColor)
+	for i, n := range nodes {
+		assert.NotNil(t, n.TintColor(), "layer %d", i)
+	}
+	assert.Nil(t, m.Groups[0].OrderedLayers()[0].TintColor())
+}
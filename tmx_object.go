@@ -55,6 +55,8 @@ type ObjectGroup struct {
 	OffsetY float64 `xml:"offsety,attr"`
 	// Whether the objects are drawn according to the order of appearance ("index") or sorted by their y-coordinate ("topdown"). Defaults to "topdown".
 	DrawOrder string `xml:"draworder,attr"`
+	// A tint color that is multiplied with any tile objects drawn by this layer. (since 1.9)
+	TintColor *HexColor `xml:"tintcolor,attr"`
 	// The parallax x factor of the layer 0 - 1.0
 	ParallaxX float32 `xml:"parallaxx,attr"`
 	// The parallax y factor of the layer 0 - 1.0
@@ -68,11 +70,14 @@ type ObjectGroup struct {
 	Properties Properties `xml:"properties>property"`
 	// Group objects
 	Objects []*Object `xml:"object"`
+	// Position of the object group in the map file
+	order int64
 }
 
 // DecodeObjectGroup decodes object group data
 func (g *ObjectGroup) DecodeObjectGroup(m *Map) error {
 	for _, object := range g.Objects {
+		object._map = m
 		if object.GID > 0 {
 			// Initialize all tilesets that are referenced by tile objects. Otherwise,
 			// if a tileset is used by an object tile but not used by any layer it
@@ -94,12 +99,14 @@ func (g *ObjectGroup) DecodeObjectGroup(m *Map) error {
 func (g *ObjectGroup) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	item := aliasObjectGroup{}
 	item.SetDefaults()
+	order := d.InputOffset()
 
 	if err := d.DecodeElement(&item, &start); err != nil {
 		return err
 	}
 
 	*g = (ObjectGroup)(item)
+	g.order = order
 
 	return nil
 }
@@ -141,12 +148,18 @@ type Object struct {
 	Polygons []*Polygon `xml:"polygon"`
 	// Poly lines
 	PolyLines []*PolyLine `xml:"polyline"`
+	// Used to mark an object as a point. The existing x and y attributes are used to determine the position of the point. (since 1.1)
+	Point *ObjectPoint `xml:"point"`
 	// Text
 	Text *Text `xml:"text"`
 	// Template
 	TemplateSource string `xml:"template,attr"`
 	TemplateLoaded bool   `xml:"-"`
 	Template       *Template
+
+	// Map the object was loaded with, if any, used to resolve tile object
+	// alignment.
+	_map *Map
 }
 
 func (o *Object) initTemplate(m *Map) error {
@@ -198,6 +211,9 @@ func (o *Object) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 // Ellipse is used to mark an object as an ellipse.
 type Ellipse struct{}
 
+// ObjectPoint is used to mark an object as a point.
+type ObjectPoint struct{}
+
 // Polygon object is made up of a space-delimited list of x,y coordinates. The origin for these coordinates is the location of the parent object.
 // By default, the first point is created as 0,0 denoting that the point will originate exactly where the object is placed.
 type Polygon struct {
@@ -0,0 +1,306 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// indexCellTiles is the size of an ObjectIndex grid cell, in map tiles.
+	indexCellTiles = 4
+	// indexMaxCells is the number of grid cells above which an object is kept
+	// in a separate list checked by every query instead of in the grid.
+	indexMaxCells = 256
+)
+
+// ObjectIndex is a spatial index over objects, answering which of them lie at
+// a point, within a rectangle or within a radius.
+//
+// Queries take each object's actual shape into account, including its
+// rotation: rectangles, ellipses, polygons, polylines, points and the image
+// box of tile objects. All positions are in pixel coordinates, the space
+// object positions are stored in (see Map.ScreenToPixel). Layer offsets are
+// not applied.
+//
+// An ObjectIndex isn't safe for concurrent use while objects are being
+// inserted or removed.
+type ObjectIndex struct {
+	cellWidth, cellHeight float64
+	cells                 map[[2]int][]*indexEntry
+	large                 []*indexEntry
+	entries               map[*Object]*indexEntry
+	seq                   int
+}
+
+// indexEntry holds an object's outline in pixel coordinates: a closed polygon,
+// an open polyline or a single point.
+type indexEntry struct {
+	obj     *Object
+	seq     int
+	bounds  Rect
+	outline []Point
+	closed  bool
+	// ellipse is set for ellipse objects, whose outline is only an
+	// approximation, so that queries can be answered exactly.
+	ellipse *Shape
+	// cells is the range of grid cells the entry was added to, or nil if it's
+	// in the large list.
+	cells *[4]int
+}
+
+// NewObjectIndex builds an ObjectIndex over the objects of every object group
+// of m, including the ones nested in group layers.
+func NewObjectIndex(m *Map) *ObjectIndex {
+	cellWidth, cellHeight := float64(m.TileWidth), float64(m.TileHeight)
+	if m.Orientation == "isometric" {
+		cellWidth = cellHeight
+	}
+	idx := NewObjectIndexWithCellSize(cellWidth*indexCellTiles, cellHeight*indexCellTiles)
+
+	var addGroups func(groups []*Group)
+	addGroups = func(groups []*Group) {
+		for _, g := range groups {
+			for _, og := range g.ObjectGroups {
+				idx.InsertAll(og.Objects)
+			}
+			addGroups(g.Groups)
+		}
+	}
+	for _, og := range m.ObjectGroups {
+		idx.InsertAll(og.Objects)
+	}
+	addGroups(m.Groups)
+
+	return idx
+}
+
+// NewObjectIndexWithCellSize returns an empty ObjectIndex using a grid of the
+// given cell size, in pixels. Non-positive sizes default to 128 pixels.
+func NewObjectIndexWithCellSize(cellWidth, cellHeight float64) *ObjectIndex {
+	if cellWidth <= 0 {
+		cellWidth = 128
+	}
+	if cellHeight <= 0 {
+		cellHeight = 128
+	}
+	return &ObjectIndex{
+		cellWidth:  cellWidth,
+		cellHeight: cellHeight,
+		cells:      make(map[[2]int][]*indexEntry),
+		entries:    make(map[*Object]*indexEntry),
+	}
+}
+
+// Len returns the number of objects in the index.
+func (idx *ObjectIndex) Len() int {
+	return len(idx.entries)
+}
+
+// InsertAll adds all of objects to the index.
+func (idx *ObjectIndex) InsertAll(objects []*Object) {
+	for _, o := range objects {
+		idx.Insert(o)
+	}
+}
+
+// Insert adds o to the index. Inserting an object that's already in the index
+// updates it, which is needed after changing its position, size or shape.
+// Query results are ordered by when objects were first inserted.
+func (idx *ObjectIndex) Insert(o *Object) {
+	seq := idx.seq
+	if old, ok := idx.entries[o]; ok {
+		seq = old.seq
+		idx.Remove(o)
+	} else {
+		idx.seq++
+	}
+
+	e := newIndexEntry(o)
+	e.seq = seq
+	idx.entries[o] = e
+
+	x0, y0, x1, y1 := idx.cellRange(e.bounds)
+	if (x1-x0+1)*(y1-y0+1) > indexMaxCells {
+		idx.large = append(idx.large, e)
+		return
+	}
+	e.cells = &[4]int{x0, y0, x1, y1}
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			key := [2]int{x, y}
+			idx.cells[key] = append(idx.cells[key], e)
+		}
+	}
+}
+
+// Remove removes o from the index, if present.
+func (idx *ObjectIndex) Remove(o *Object) {
+	e, ok := idx.entries[o]
+	if !ok {
+		return
+	}
+	delete(idx.entries, o)
+
+	if e.cells == nil {
+		idx.large = removeEntry(idx.large, e)
+		return
+	}
+	for y := e.cells[1]; y <= e.cells[3]; y++ {
+		for x := e.cells[0]; x <= e.cells[2]; x++ {
+			key := [2]int{x, y}
+			if l := removeEntry(idx.cells[key], e); len(l) > 0 {
+				idx.cells[key] = l
+			} else {
+				delete(idx.cells, key)
+			}
+		}
+	}
+}
+
+func removeEntry(entries []*indexEntry, e *indexEntry) []*indexEntry {
+	for i, c := range entries {
+		if c == e {
+			return append(entries[:i], entries[i+1:]...)
+		}
+	}
+	return entries
+}
+
+// QueryPoint returns the objects whose shape contains the point (x, y).
+// Points and polylines have no area, so they're never returned; use
+// QueryRadius to pick them with some tolerance.
+func (idx *ObjectIndex) QueryPoint(x, y float64) []*Object {
+	return idx.query(Rect{Min: Point{X: x, Y: y}, Max: Point{X: x, Y: y}}, func(e *indexEntry) bool {
+		if e.ellipse != nil {
+			return e.ellipse.Contains(x, y)
+		}
+		return e.closed && polygonContains(e.outline, x, y)
+	})
+}
+
+// QueryRect returns the objects whose shape shares at least one point with r.
+func (idx *ObjectIndex) QueryRect(r Rect) []*Object {
+	return idx.query(r, func(e *indexEntry) bool {
+		if e.ellipse != nil {
+			return ellipseIntersectsRect(*e.ellipse, r)
+		}
+		return polyIntersectsRect(e.outline, e.closed, r)
+	})
+}
+
+// QueryRadius returns the objects whose shape shares at least one point with
+// the circle of the given radius around (x, y).
+func (idx *ObjectIndex) QueryRadius(x, y, radius float64) []*Object {
+	r := Rect{Min: Point{X: x - radius, Y: y - radius}, Max: Point{X: x + radius, Y: y + radius}}
+	return idx.query(r, func(e *indexEntry) bool {
+		if e.ellipse != nil {
+			return ellipseDistance(*e.ellipse, x, y) <= radius
+		}
+		return polyIntersectsCircle(e.outline, e.closed, x, y, radius)
+	})
+}
+
+func (idx *ObjectIndex) query(r Rect, match func(e *indexEntry) bool) []*Object {
+	var found []*indexEntry
+	seen := make(map[*indexEntry]bool)
+	test := func(e *indexEntry) {
+		if seen[e] {
+			return
+		}
+		seen[e] = true
+		if e.bounds.Intersects(r) && match(e) {
+			found = append(found, e)
+		}
+	}
+
+	x0, y0, x1, y1 := idx.cellRange(r)
+	if (x1-x0+1)*(y1-y0+1) > len(idx.cells) {
+		// Cheaper to look at every occupied cell than at every covered one.
+		for key, entries := range idx.cells {
+			if key[0] < x0 || key[0] > x1 || key[1] < y0 || key[1] > y1 {
+				continue
+			}
+			for _, e := range entries {
+				test(e)
+			}
+		}
+	} else {
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				for _, e := range idx.cells[[2]int{x, y}] {
+					test(e)
+				}
+			}
+		}
+	}
+	for _, e := range idx.large {
+		test(e)
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].seq < found[j].seq
+	})
+	objects := make([]*Object, len(found))
+	for i, e := range found {
+		objects[i] = e.obj
+	}
+	return objects
+}
+
+// cellRange returns the range of grid cells, inclusive, covered by r.
+func (idx *ObjectIndex) cellRange(r Rect) (x0, y0, x1, y1 int) {
+	return int(math.Floor(r.Min.X / idx.cellWidth)), int(math.Floor(r.Min.Y / idx.cellHeight)),
+		int(math.Floor(r.Max.X / idx.cellWidth)), int(math.Floor(r.Max.Y / idx.cellHeight))
+}
+
+func newIndexEntry(o *Object) *indexEntry {
+	e := &indexEntry{obj: o}
+	shape := o.Shape()
+
+	switch {
+	case shape.Kind == ShapeEllipse && shape.Box.Dx() > 0 && shape.Box.Dy() > 0:
+		e.outline = shape.Vertices()
+		e.closed = true
+		e.ellipse = &shape
+	case shape.Kind == ShapeEllipse, shape.Kind == ShapeRectangle && o.Width == 0 && o.Height == 0:
+		// Degenerate shapes without an area are picked like points.
+		e.outline = []Point{shape.Origin}
+	default:
+		e.outline = shape.Vertices()
+		e.closed = shape.Closed()
+	}
+
+	if len(e.outline) == 0 {
+		e.outline = []Point{shape.Origin}
+		e.closed = false
+	}
+	e.bounds = boundsOf(e.outline)
+	if e.ellipse != nil {
+		// The approximating polygon lies within the ellipse, so its bounds
+		// may fall slightly short of the actual ones.
+		e.bounds = e.bounds.Union(shape.Bounds())
+	}
+	return e
+}
@@ -0,0 +1,165 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testIndexObjects() (rect, rotated, ellipse, triangle, line, point, tile *Object) {
+	rect = &Object{ID: 1, X: 0, Y: 0, Width: 20, Height: 10}
+	// A 20x20 square rotated around its top-left corner into a diamond with
+	// its corners at (100,0), (114,14), (100,28) and (86,14).
+	rotated = &Object{ID: 2, X: 100, Y: 0, Width: 20, Height: 20, Rotation: 45}
+	ellipse = &Object{ID: 3, X: 200, Y: 0, Width: 40, Height: 20, Ellipses: []*Ellipse{{}}}
+	triangle = &Object{ID: 4, X: 300, Y: 0, Polygons: []*Polygon{{Points: &Points{{X: 0, Y: 0}, {X: 40, Y: 0}, {X: 0, Y: 40}}}}}
+	line = &Object{ID: 5, X: 400, Y: 0, PolyLines: []*PolyLine{{Points: &Points{{X: 0, Y: 0}, {X: 40, Y: 40}}}}}
+	point = &Object{ID: 6, X: 500, Y: 10}
+	tile = &Object{ID: 7, X: 600, Y: 32, Width: 16, Height: 32, GID: 1}
+	return
+}
+
+func TestObjectIndex_QueryPoint(t *testing.T) {
+	rect, rotated, ellipse, triangle, line, point, tile := testIndexObjects()
+	idx := NewObjectIndexWithCellSize(64, 64)
+	idx.InsertAll([]*Object{rect, rotated, ellipse, triangle, line, point, tile})
+	assert.Equal(t, 7, idx.Len())
+
+	assert.Equal(t, []*Object{rect}, idx.QueryPoint(10, 5))
+	assert.Empty(t, idx.QueryPoint(10, 11))
+
+	// Inside the diamond, but outside of the unrotated square; and inside the
+	// unrotated square, but outside of the diamond.
+	assert.Equal(t, []*Object{rotated}, idx.QueryPoint(90, 14))
+	assert.Empty(t, idx.QueryPoint(118, 2))
+
+	// Inside the ellipse, and inside its bounding box but outside of it.
+	assert.Equal(t, []*Object{ellipse}, idx.QueryPoint(220, 10))
+	assert.Empty(t, idx.QueryPoint(202, 2))
+
+	assert.Equal(t, []*Object{triangle}, idx.QueryPoint(305, 5))
+	assert.Empty(t, idx.QueryPoint(335, 35))
+
+	assert.Empty(t, idx.QueryPoint(420, 20))
+	assert.Empty(t, idx.QueryPoint(500, 10))
+
+	// Tile objects extend upwards from their position.
+	assert.Equal(t, []*Object{tile}, idx.QueryPoint(608, 16))
+	assert.Empty(t, idx.QueryPoint(608, 40))
+}
+
+func TestObjectIndex_QueryRectAndRadius(t *testing.T) {
+	rect, rotated, ellipse, triangle, line, point, tile := testIndexObjects()
+	idx := NewObjectIndexWithCellSize(64, 64)
+	idx.InsertAll([]*Object{rect, rotated, ellipse, triangle, line, point, tile})
+
+	assert.Equal(t, []*Object{rect, rotated, ellipse, triangle, line, point, tile}, idx.QueryRect(NewRect(-10, -10, 1000, 100)))
+	assert.Equal(t, []*Object{line}, idx.QueryRect(NewRect(415, 25, 10, 2)))
+	assert.Empty(t, idx.QueryRect(NewRect(430, 0, 10, 10)))
+	assert.Empty(t, idx.QueryRect(NewRect(116, 0, 10, 5)))
+
+	assert.Equal(t, []*Object{point}, idx.QueryRadius(503, 14, 5))
+	assert.Empty(t, idx.QueryRadius(503, 14, 4))
+	assert.Equal(t, []*Object{line}, idx.QueryRadius(430, 10, 15))
+	assert.Equal(t, []*Object{triangle}, idx.QueryRadius(325, 25, 8))
+	assert.Empty(t, idx.QueryRadius(325, 25, 6))
+}
+
+func TestObjectIndex_EllipseBoundary(t *testing.T) {
+	large := &Object{ID: 1, Width: 2000, Height: 2000, Ellipses: []*Ellipse{{}}}
+	// 100x200 once rotated, centered at (-50, 100).
+	rotated := &Object{ID: 2, Width: 200, Height: 100, Rotation: 90, Ellipses: []*Ellipse{{}}}
+	idx := NewObjectIndex(&Map{TileWidth: 16, TileHeight: 16})
+	idx.InsertAll([]*Object{large, rotated})
+
+	// Close to the boundary of the large ellipse, where its approximating
+	// polygon falls several pixels short.
+	sin, cos := math.Sincos(math.Pi / 32)
+	x, y := 1000+998*cos, 1000+998*sin
+	assert.Equal(t, []*Object{large}, idx.QueryRect(NewRect(x, y, 0.5, 0.5)))
+	x, y = 1000+1001*cos, 1000+1001*sin
+	assert.Empty(t, idx.QueryRect(NewRect(x, y, 0.5, 0.5)))
+	assert.Equal(t, []*Object{large}, idx.QueryRadius(x, y, 2))
+	assert.Empty(t, idx.QueryRadius(x, y, 0.9))
+
+	assert.Equal(t, []*Object{rotated}, idx.QueryRect(NewRect(-51, 199.5, 2, 0.4)))
+	assert.Empty(t, idx.QueryRect(NewRect(-51, 200.1, 2, 1)))
+	assert.Equal(t, []*Object{rotated}, idx.QueryRect(NewRect(-110, 90, 10, 20)))
+	assert.Equal(t, []*Object{rotated}, idx.QueryRadius(-50, 203, 3.5))
+	assert.Empty(t, idx.QueryRadius(-50, 203, 2.5))
+	assert.Equal(t, []*Object{rotated}, idx.QueryRadius(3, 100, 3.5))
+	assert.Empty(t, idx.QueryRadius(3, 100, 2.5))
+}
+
+func TestPointEllipseDistance(t *testing.T) {
+	for _, p := range []Point{{X: 0, Y: 0}, {X: 3, Y: 0}, {X: 30, Y: 0}, {X: 0, Y: 1}, {X: 0, Y: 12}, {X: 5, Y: 5}, {X: 19, Y: 2}, {X: 1, Y: 9}} {
+		// Compare with the closest of many points sampled on the ellipse.
+		want := math.Inf(1)
+		for i := 0; i <= 100000; i++ {
+			sin, cos := math.Sincos(float64(i) / 100000 * math.Pi / 2)
+			want = math.Min(want, math.Hypot(20*cos-p.X, 10*sin-p.Y))
+		}
+		assert.InDelta(t, want, pointEllipseDistance(20, 10, p.X, p.Y), 1e-3, "point %v", p)
+	}
+}
+
+func TestObjectIndex_InsertRemove(t *testing.T) {
+	rect, _, _, _, _, _, _ := testIndexObjects()
+	huge := &Object{ID: 8, X: -10000, Y: -10000, Width: 20000, Height: 20000}
+
+	idx := NewObjectIndexWithCellSize(64, 64)
+	idx.Insert(huge)
+	idx.Insert(rect)
+	assert.Equal(t, []*Object{huge, rect}, idx.QueryPoint(5, 5))
+
+	// Moving an object requires it to be inserted again, and doesn't change
+	// its position in query results.
+	rect.X = 1000
+	idx.Insert(huge)
+	idx.Insert(rect)
+	assert.Equal(t, []*Object{huge}, idx.QueryPoint(5, 5))
+	assert.Equal(t, []*Object{huge, rect}, idx.QueryPoint(1005, 5))
+
+	idx.Remove(huge)
+	assert.Equal(t, []*Object{rect}, idx.QueryPoint(1005, 5))
+	assert.Equal(t, 1, idx.Len())
+}
+
+func TestNewObjectIndex(t *testing.T) {
+	m, err := LoadFile(filepath.Join(GetAssetsDirectory(), "test_render_objects.tmx"))
+	assert.NoError(t, err)
+
+	objects := m.ObjectGroups[0].Objects
+	idx := NewObjectIndex(m)
+	assert.Equal(t, len(objects), idx.Len())
+
+	// Object 1 is an unrotated 32x32 tile object at (0, 32).
+	if assert.Contains(t, idx.QueryPoint(16, 16), objects[0]) {
+		assert.Equal(t, uint32(1), objects[0].ID)
+	}
+	assert.NotContains(t, idx.QueryPoint(16, 40), objects[0])
+}
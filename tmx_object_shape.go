@@ -0,0 +1,257 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import "math"
+
+// DefaultEllipseSegments is the number of vertices ellipses are approximated
+// with by Shape.Vertices.
+const DefaultEllipseSegments = 32
+
+// ShapeKind is the kind of shape an object represents.
+type ShapeKind int
+
+const (
+	// ShapeRectangle is a plain rectangle object.
+	ShapeRectangle ShapeKind = iota
+	// ShapeEllipse is an ellipse inscribed in the object's rectangle.
+	ShapeEllipse
+	// ShapePoint is a point object, which has no size.
+	ShapePoint
+	// ShapePolygon is a closed polygon.
+	ShapePolygon
+	// ShapePolyline is an open polyline.
+	ShapePolyline
+	// ShapeText is a text object, occupying the object's rectangle.
+	ShapeText
+	// ShapeTile is a tile object, occupying the rectangle its tile image is
+	// drawn in.
+	ShapeTile
+)
+
+// Shape is the geometry of an object in pixel coordinates.
+type Shape struct {
+	// Kind of the shape.
+	Kind ShapeKind
+	// Origin is the object's position, which the shape is rotated around.
+	Origin Point
+	// Rotation of the shape in degrees clockwise.
+	Rotation float64
+	// Box is the unrotated rectangle of rectangle, ellipse, text and tile
+	// shapes, relative to Origin. Tile objects are aligned to their position
+	// according to their tileset's ObjectAlignment, which by default means the
+	// box extends upwards from Origin.
+	Box Rect
+	// Points are the unrotated vertices of polygon and polyline shapes,
+	// relative to Origin.
+	Points []Point
+}
+
+// Shape returns the geometry of the object.
+//
+// On isometric maps tile objects are drawn in screen space, so the returned
+// box, which uses pixel coordinates like every other shape, only approximates
+// the area covered by the tile image.
+func (o *Object) Shape() Shape {
+	s := Shape{
+		Origin:   Point{X: o.X, Y: o.Y},
+		Rotation: o.Rotation,
+		Box:      NewRect(0, 0, o.Width, o.Height),
+	}
+
+	copyPoints := func(points *Points) []Point {
+		if points == nil {
+			return nil
+		}
+		l := make([]Point, len(*points))
+		for i, p := range *points {
+			l[i] = *p
+		}
+		return l
+	}
+
+	switch {
+	case o.GID != 0:
+		s.Kind = ShapeTile
+		ax, ay := o.alignmentAnchor()
+		s.Box = NewRect(-ax*o.Width, -ay*o.Height, o.Width, o.Height)
+	case o.Point != nil:
+		s.Kind = ShapePoint
+		s.Box = Rect{}
+	case len(o.Ellipses) > 0:
+		s.Kind = ShapeEllipse
+	case len(o.Polygons) > 0:
+		s.Kind = ShapePolygon
+		s.Points = copyPoints(o.Polygons[0].Points)
+	case len(o.PolyLines) > 0:
+		s.Kind = ShapePolyline
+		s.Points = copyPoints(o.PolyLines[0].Points)
+	case o.Text != nil:
+		s.Kind = ShapeText
+	default:
+		s.Kind = ShapeRectangle
+	}
+
+	return s
+}
+
+// alignmentAnchor returns the point of a tile object's image, as fractions of
+// its width and height, that is placed at the object's position.
+func (o *Object) alignmentAnchor() (float64, float64) {
+	alignment, orientation := "unspecified", "orthogonal"
+	if o._map != nil {
+		orientation = o._map.Orientation
+		if t, err := o._map.TileGIDToTile(o.GID); err == nil && t.Tileset != nil {
+			alignment = t.Tileset.ObjectAlignment
+		}
+	}
+	return ObjectAlignmentAnchor(alignment, orientation)
+}
+
+// ObjectAlignmentAnchor returns the point of a tile object's image, as
+// fractions of its width and height, that is placed at the object's position,
+// for the given Tileset.ObjectAlignment and Map.Orientation. Per the TMX spec,
+// "unspecified" alignment means bottom-left for all orientations except
+// isometric, where it means bottom-center.
+func ObjectAlignmentAnchor(alignment, orientation string) (float64, float64) {
+	switch alignment {
+	case "topleft":
+		return 0, 0
+	case "top":
+		return 0.5, 0
+	case "topright":
+		return 1, 0
+	case "left":
+		return 0, 0.5
+	case "center":
+		return 0.5, 0.5
+	case "right":
+		return 1, 0.5
+	case "bottomleft":
+		return 0, 1
+	case "bottom":
+		return 0.5, 1
+	case "bottomright":
+		return 1, 1
+	}
+	if orientation == "isometric" {
+		return 0.5, 1
+	}
+	return 0, 1
+}
+
+// Closed reports whether the shape encloses an area, which is the case for
+// every kind except points and polylines.
+func (s Shape) Closed() bool {
+	return s.Kind != ShapePoint && s.Kind != ShapePolyline
+}
+
+// transform rotates points given relative to the origin and moves them into
+// pixel coordinates, in place.
+func (s Shape) transform(points []Point) []Point {
+	sin, cos := math.Sincos(s.Rotation * math.Pi / 180)
+	for i, p := range points {
+		points[i] = rotateAround(Point{X: s.Origin.X + p.X, Y: s.Origin.Y + p.Y}, s.Origin.X, s.Origin.Y, sin, cos)
+	}
+	return points
+}
+
+// Vertices returns the outline of the shape in pixel coordinates, with its
+// rotation applied: the corners of rectangle, text and tile shapes clockwise
+// from the top-left one, the vertices of polygons and polylines, or the single
+// position of a point. Ellipses are approximated by a polygon with
+// DefaultEllipseSegments vertices.
+func (s Shape) Vertices() []Point {
+	return s.Polygon(DefaultEllipseSegments)
+}
+
+// Polygon is like Vertices, but approximates ellipses with the given number of
+// vertices, which is at least 3.
+func (s Shape) Polygon(segments int) []Point {
+	switch s.Kind {
+	case ShapePoint:
+		return []Point{s.Origin}
+	case ShapePolygon, ShapePolyline:
+		return s.transform(append([]Point(nil), s.Points...))
+	case ShapeEllipse:
+		segments = max(segments, 3)
+		rx, ry := s.Box.Dx()/2, s.Box.Dy()/2
+		cx, cy := s.Box.Min.X+rx, s.Box.Min.Y+ry
+		points := make([]Point, segments)
+		for i := range points {
+			sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(segments))
+			points[i] = Point{X: cx + rx*cos, Y: cy + ry*sin}
+		}
+		return s.transform(points)
+	default:
+		b := s.Box
+		return s.transform([]Point{b.Min, {X: b.Max.X, Y: b.Min.Y}, b.Max, {X: b.Min.X, Y: b.Max.Y}})
+	}
+}
+
+// Bounds returns the smallest axis-aligned rectangle containing the shape, in
+// pixel coordinates, with its rotation applied. Unlike Vertices, it is exact
+// for ellipses.
+func (s Shape) Bounds() Rect {
+	if s.Kind == ShapeEllipse {
+		sin, cos := math.Sincos(s.Rotation * math.Pi / 180)
+		rx, ry := s.Box.Dx()/2, s.Box.Dy()/2
+		c := s.transform([]Point{{X: s.Box.Min.X + rx, Y: s.Box.Min.Y + ry}})[0]
+		ex := math.Hypot(rx*cos, ry*sin)
+		ey := math.Hypot(rx*sin, ry*cos)
+		return Rect{Min: Point{X: c.X - ex, Y: c.Y - ey}, Max: Point{X: c.X + ex, Y: c.Y + ey}}
+	}
+
+	points := s.Vertices()
+	if len(points) == 0 {
+		return Rect{Min: s.Origin, Max: s.Origin}
+	}
+	return boundsOf(points)
+}
+
+// Contains reports whether the point (x, y), in pixel coordinates, lies within
+// the shape. Points and polylines enclose no area and never contain anything.
+func (s Shape) Contains(x, y float64) bool {
+	switch s.Kind {
+	case ShapePoint, ShapePolyline:
+		return false
+	case ShapePolygon:
+		return len(s.Points) > 2 && polygonContains(s.Vertices(), x, y)
+	}
+
+	// Rotate the point back into the unrotated space of the box.
+	sin, cos := math.Sincos(-s.Rotation * math.Pi / 180)
+	p := rotateAround(Point{X: x, Y: y}, s.Origin.X, s.Origin.Y, sin, cos)
+	px, py := p.X-s.Origin.X, p.Y-s.Origin.Y
+
+	if s.Kind == ShapeEllipse {
+		rx, ry := s.Box.Dx()/2, s.Box.Dy()/2
+		if rx <= 0 || ry <= 0 {
+			return false
+		}
+		dx := (px - s.Box.Min.X - rx) / rx
+		dy := (py - s.Box.Min.Y - ry) / ry
+		return dx*dx+dy*dy <= 1
+	}
+	return s.Box.Contains(px, py)
+}
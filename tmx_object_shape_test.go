@@ -0,0 +1,135 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testShapesMap = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="4" height="4" tilewidth="16" tileheight="16" nextlayerid="2" nextobjectid="7">
+ <tileset firstgid="1" name="aligned" tilewidth="16" tileheight="16" tilecount="1" columns="1" objectalignment="center">
+  <image source="tilesets/tileset.png" width="16" height="16"/>
+ </tileset>
+ <objectgroup id="1" name="Objects">
+  <object id="1" x="8" y="8">
+   <point/>
+  </object>
+  <object id="2" x="16" y="16" width="32" height="16">
+   <ellipse/>
+  </object>
+  <object id="3" x="0" y="0" width="40" height="20">
+   <text wrap="1">Hello</text>
+  </object>
+  <object id="4" gid="1" x="32" y="32" width="16" height="16"/>
+  <object id="5" x="0" y="0" width="10" height="10"/>
+  <object id="6" x="10" y="10">
+   <polyline points="0,0 10,0 10,10"/>
+  </object>
+ </objectgroup>
+</map>
+`
+
+func assertPointsInDelta(t *testing.T, expected, actual []Point) {
+	t.Helper()
+	if assert.Len(t, actual, len(expected)) {
+		for i := range expected {
+			assert.InDelta(t, expected[i].X, actual[i].X, 1e-9)
+			assert.InDelta(t, expected[i].Y, actual[i].Y, 1e-9)
+		}
+	}
+}
+
+func TestObjectShape_Kinds(t *testing.T) {
+	m, err := LoadReader(GetAssetsDirectory(), strings.NewReader(testShapesMap))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	objects := m.ObjectGroups[0].Objects
+	if !assert.Len(t, objects, 6) {
+		return
+	}
+
+	assert.NotNil(t, objects[0].Point)
+	kinds := []ShapeKind{ShapePoint, ShapeEllipse, ShapeText, ShapeTile, ShapeRectangle, ShapePolyline}
+	for i, kind := range kinds {
+		assert.Equal(t, kind, objects[i].Shape().Kind, "object %d", objects[i].ID)
+	}
+
+	assert.Equal(t, []Point{{X: 8, Y: 8}}, objects[0].Shape().Vertices())
+	assert.False(t, objects[0].Shape().Closed())
+	assert.False(t, objects[5].Shape().Closed())
+	assert.Equal(t, []Point{{X: 10, Y: 10}, {X: 20, Y: 10}, {X: 20, Y: 20}}, objects[5].Shape().Vertices())
+
+	// The tileset aligns tile objects at their center.
+	tile := objects[3].Shape()
+	assert.Equal(t, NewRect(-8, -8, 16, 16), tile.Box)
+	assert.Equal(t, NewRect(24, 24, 16, 16), tile.Bounds())
+	assert.True(t, tile.Contains(25, 39))
+	assert.False(t, tile.Contains(41, 39))
+}
+
+func TestObjectShape_Rotation(t *testing.T) {
+	rect := (&Object{X: 10, Y: 10, Width: 20, Height: 10, Rotation: 90}).Shape()
+	assertPointsInDelta(t, []Point{{X: 10, Y: 10}, {X: 10, Y: 30}, {X: 0, Y: 30}, {X: 0, Y: 10}}, rect.Vertices())
+	assert.True(t, rect.Contains(5, 25))
+	assert.False(t, rect.Contains(15, 15))
+
+	b := rect.Bounds()
+	assert.InDelta(t, 0, b.Min.X, 1e-9)
+	assert.InDelta(t, 10, b.Min.Y, 1e-9)
+	assert.InDelta(t, 10, b.Max.X, 1e-9)
+	assert.InDelta(t, 30, b.Max.Y, 1e-9)
+
+	// Tile objects without an explicit alignment are anchored at their
+	// bottom-left corner, or bottom center on isometric maps.
+	tile := (&Object{X: 10, Y: 10, Width: 20, Height: 10, GID: 1}).Shape()
+	assert.Equal(t, NewRect(0, -10, 20, 10), tile.Box)
+	ax, ay := ObjectAlignmentAnchor("unspecified", "isometric")
+	assert.Equal(t, 0.5, ax)
+	assert.Equal(t, 1.0, ay)
+}
+
+func TestObjectShape_Ellipse(t *testing.T) {
+	ellipse := (&Object{X: 0, Y: 0, Width: 40, Height: 20, Ellipses: []*Ellipse{{}}}).Shape()
+	assert.Len(t, ellipse.Vertices(), DefaultEllipseSegments)
+	assertPointsInDelta(t, []Point{{X: 40, Y: 10}, {X: 20, Y: 20}, {X: 0, Y: 10}, {X: 20, Y: 0}}, ellipse.Polygon(4))
+	assert.Equal(t, NewRect(0, 0, 40, 20), ellipse.Bounds())
+	assert.True(t, ellipse.Contains(20, 10))
+	assert.False(t, ellipse.Contains(2, 2))
+
+	// Rotated by 90 degrees around its top-left corner, the ellipse spans
+	// from (-20, 0) to (0, 40).
+	ellipse.Rotation = 90
+	b := ellipse.Bounds()
+	assert.InDelta(t, -20, b.Min.X, 1e-9)
+	assert.InDelta(t, 0, b.Min.Y, 1e-9)
+	assert.InDelta(t, 0, b.Max.X, 1e-9)
+	assert.InDelta(t, 40, b.Max.Y, 1e-9)
+	assert.True(t, ellipse.Contains(-10, 20))
+	assert.False(t, ellipse.Contains(10, 20))
+}
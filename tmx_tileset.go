@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 )
 
+// ErrInvalidTileID is returned when a tileset has no tile with the given ID.
+var ErrInvalidTileID = errors.New("tiled: invalid tile ID")
+
 // Tileset is collection of tiles
 type Tileset struct {
 	// Base directory
@@ -209,6 +212,11 @@ func (t *TilesetTile) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 	*t = (TilesetTile)(item)
 	t.Class, t.Type = resolveClassType(t.Class, t.Type)
 
+	// The collision shapes of tiles aren't layers of the map.
+	for _, og := range t.ObjectGroups {
+		og.order = 0
+	}
+
 	// Per the TMX spec, Probability defaults to 1
 	if t.Probability == 0 {
 		t.Probability = 1
@@ -236,13 +244,24 @@ type AnimationFrame struct {
 	Duration uint32 `xml:"duration,attr"`
 }
 
-// GetTileRect returns a rectangle that contains the tile in the tileset.Image
+// GetTileRect returns a rectangle that contains the tile in the tileset.Image.
+// For tiles of image collection tilesets it returns the tile's sub-rectangle
+// of its own image; see TileImageSource.
 func (ts *Tileset) GetTileRect(tileID uint32) image.Rectangle {
+	if ts.Image == nil {
+		if _, rect, err := ts.TileImageSource(tileID); err == nil {
+			return rect
+		}
+	}
+
 	tilesetColumns := ts.Columns
 
-	if tilesetColumns == 0 {
+	if tilesetColumns == 0 && ts.Image != nil && ts.TileWidth+ts.Spacing > 0 {
 		tilesetColumns = ts.Image.Width / (ts.TileWidth + ts.Spacing)
 	}
+	if tilesetColumns <= 0 {
+		tilesetColumns = 1
+	}
 
 	x := int(tileID) % tilesetColumns
 	y := int(tileID) / tilesetColumns
@@ -256,6 +275,31 @@ func (ts *Tileset) GetTileRect(tileID uint32) image.Rectangle {
 		(y+1)*ts.TileHeight+yOffset)
 }
 
+// TileImageSource returns the image the tile with the given ID is cut from and
+// its rectangle within that image, for both tilesets based on a single image
+// and image collection tilesets, where each tile has its own image and may use
+// a sub-rectangle of it (since 1.9). A zero width or height means the tile
+// extends to the right or bottom edge of an image whose size wasn't saved.
+func (ts *Tileset) TileImageSource(tileID uint32) (*Image, image.Rectangle, error) {
+	if ts.Image != nil {
+		if ts.TileCount > 0 && int(tileID) >= ts.TileCount {
+			return nil, image.Rectangle{}, ErrInvalidTileID
+		}
+		return ts.Image, ts.GetTileRect(tileID), nil
+	}
+
+	for _, t := range ts.Tiles {
+		if t.ID != tileID {
+			continue
+		}
+		if t.Image == nil {
+			break
+		}
+		return t.Image, image.Rect(t.X, t.Y, t.X+t.Width, t.Y+t.Height), nil
+	}
+	return nil, image.Rectangle{}, ErrInvalidTileID
+}
+
 // GetTilesetTile returns TilesetTile by tileID
 func (ts *Tileset) GetTilesetTile(tileID uint32) (*TilesetTile, error) {
 	var tile *TilesetTile
@@ -26,6 +26,7 @@ import (
 	"image"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -156,6 +157,28 @@ func TestGetTileRect(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Image collection",
+			ts: Tileset{
+				TileCount:  2,
+				TileWidth:  16,
+				TileHeight: 16,
+				Tiles: []*TilesetTile{
+					{ID: 0, Image: &Image{Source: "a.png", Width: 16, Height: 16}, Width: 16, Height: 16},
+					{ID: 3, Image: &Image{Source: "sheet.png", Width: 64, Height: 32}, X: 16, Y: 8, Width: 8, Height: 4},
+				},
+			},
+			cases: []Case{
+				{
+					id:   0,
+					rect: image.Rect(0, 0, 16, 16),
+				},
+				{
+					id:   3,
+					rect: image.Rect(16, 8, 24, 12),
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -168,6 +191,44 @@ func TestGetTileRect(t *testing.T) {
 	}
 }
 
+func TestTileset_TileImageSource(t *testing.T) {
+	ts, err := LoadTilesetReader(".", strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" name="sprites" tilewidth="16" tileheight="16" tilecount="2" columns="0">
+ <grid orientation="orthogonal" width="1" height="1"/>
+ <tile id="0">
+  <image source="whole.png" width="16" height="16"/>
+ </tile>
+ <tile id="4" x="16" y="8" width="8" height="4">
+  <image source="sheet.png" width="64" height="32"/>
+ </tile>
+</tileset>`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	img, rect, err := ts.TileImageSource(0)
+	assert.NoError(t, err)
+	assert.Equal(t, "whole.png", img.Source)
+	assert.Equal(t, image.Rect(0, 0, 16, 16), rect)
+
+	img, rect, err = ts.TileImageSource(4)
+	assert.NoError(t, err)
+	assert.Equal(t, "sheet.png", img.Source)
+	assert.Equal(t, image.Rect(16, 8, 24, 12), rect)
+
+	_, _, err = ts.TileImageSource(1)
+	assert.ErrorIs(t, err, ErrInvalidTileID)
+
+	atlas := &Tileset{TileCount: 4, Columns: 2, TileWidth: 10, TileHeight: 10, Image: &Image{Source: "atlas.png", Width: 20, Height: 20}}
+	img, rect, err = atlas.TileImageSource(3)
+	assert.NoError(t, err)
+	assert.Equal(t, "atlas.png", img.Source)
+	assert.Equal(t, image.Rect(10, 10, 20, 20), rect)
+
+	_, _, err = atlas.TileImageSource(4)
+	assert.ErrorIs(t, err, ErrInvalidTileID)
+}
+
 var testLoadTilesetFile = &Tileset{
 	baseDir:  ".",
 	Columns:  64,
@@ -0,0 +1,427 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import (
+	"errors"
+	"math/rand"
+)
+
+var (
+	// ErrNoWangTiles error is returned when a wang set has no usable wang tiles
+	ErrNoWangTiles = errors.New("tiled: wang set has no wang tiles")
+	// ErrLayerNotDecoded error is returned when a layer has no decoded tiles to change
+	ErrLayerNotDecoded = errors.New("tiled: layer tiles not decoded")
+)
+
+// WangColorGrid is a grid of cells with the wang colors that should meet at
+// their corners and edges, used to pick matching tiles with a WangFiller.
+//
+// Colors are indexes into WangSet.WangColors starting from 1, with 0 meaning
+// that any color may be used. Neighbouring cells share their corners and
+// edges, so setting the color of one changes it for every cell it touches.
+type WangColorGrid struct {
+	width, height int
+	// corners are the colors of the (width+1)x(height+1) cell corners.
+	corners []uint8
+	// hEdges are the colors of the width x (height+1) horizontal edges.
+	hEdges []uint8
+	// vEdges are the colors of the (width+1) x height vertical edges.
+	vEdges []uint8
+}
+
+// NewWangColorGrid returns an empty grid of the given size in cells.
+func NewWangColorGrid(width, height int) *WangColorGrid {
+	return &WangColorGrid{
+		width:   width,
+		height:  height,
+		corners: make([]uint8, (width+1)*(height+1)),
+		hEdges:  make([]uint8, width*(height+1)),
+		vEdges:  make([]uint8, (width+1)*height),
+	}
+}
+
+// Size returns the size of the grid in cells.
+func (g *WangColorGrid) Size() (int, int) {
+	return g.width, g.height
+}
+
+// inBounds reports whether (x, y) is a cell of the grid.
+func (g *WangColorGrid) inBounds(x, y int) bool {
+	return x >= 0 && y >= 0 && x < g.width && y < g.height
+}
+
+// SetCell sets all corners and edges of the cell at (x, y) to the color, the
+// way the Tiled terrain brush paints a tile.
+func (g *WangColorGrid) SetCell(x, y int, color uint8) {
+	for _, p := range []WangPosition{Top, TopRight, Right, BottomRight, Bottom, BottomLeft, Left, TopLeft} {
+		g.Set(x, y, p, color)
+	}
+}
+
+// Set sets the color of the corner or edge at the given position of the cell
+// at (x, y). Positions outside of the grid are ignored.
+func (g *WangColorGrid) Set(x, y int, p WangPosition, color uint8) {
+	if c := g.slot(x, y, p); c != nil {
+		*c = color
+	}
+}
+
+// Get returns the color of the corner or edge at the given position of the
+// cell at (x, y), or 0 for positions outside of the grid.
+func (g *WangColorGrid) Get(x, y int, p WangPosition) uint8 {
+	if c := g.slot(x, y, p); c != nil {
+		return *c
+	}
+	return 0
+}
+
+// slot returns the storage of the corner or edge at the given position of the
+// cell at (x, y), or nil if the cell isn't in the grid.
+func (g *WangColorGrid) slot(x, y int, p WangPosition) *uint8 {
+	if !g.inBounds(x, y) {
+		return nil
+	}
+	corner := func(cx, cy int) *uint8 {
+		return &g.corners[cy*(g.width+1)+cx]
+	}
+	switch p {
+	case Top:
+		return &g.hEdges[y*g.width+x]
+	case Bottom:
+		return &g.hEdges[(y+1)*g.width+x]
+	case Left:
+		return &g.vEdges[y*(g.width+1)+x]
+	case Right:
+		return &g.vEdges[y*(g.width+1)+x+1]
+	case TopLeft:
+		return corner(x, y)
+	case TopRight:
+		return corner(x+1, y)
+	case BottomLeft:
+		return corner(x, y+1)
+	case BottomRight:
+		return corner(x+1, y+1)
+	}
+	return nil
+}
+
+// Cell returns the colors wanted for the cell at (x, y).
+func (g *WangColorGrid) Cell(x, y int) WangID {
+	var id WangID
+	for p := range id {
+		id[p] = g.Get(x, y, WangPosition(p))
+	}
+	return id
+}
+
+// WangFillerOption is used to customize a WangFiller
+type WangFillerOption func(*WangFiller)
+
+// WithWangRand sets the random number generator used to choose between
+// equally good tiles, for reproducible results. Defaults to the top-level
+// math/rand functions.
+func WithWangRand(r *rand.Rand) WangFillerOption {
+	return func(f *WangFiller) {
+		f.rand = r
+	}
+}
+
+// wangCandidate is a wang tile, possibly transformed, that the filler can
+// place.
+type wangCandidate struct {
+	tile        *LayerTile
+	id          WangID
+	probability float64
+	transformed bool
+}
+
+// WangFiller fills layers with the tiles of a wang set, picking for each cell
+// the tile whose wang colors best match a WangColorGrid, like the Tiled
+// terrain brush does.
+//
+// When the tileset's Transformations allow it, flipped and rotated variants of
+// the wang tiles are used as well.
+type WangFiller struct {
+	tileset    *Tileset
+	wangSet    *WangSet
+	candidates []*wangCandidate
+	// exact indexes the candidates by their masked colors.
+	exact map[WangID][]*wangCandidate
+	// mask marks the positions that matter for the wang set's type.
+	mask [8]bool
+	rand *rand.Rand
+}
+
+// NewWangFiller returns a filler placing the tiles of the wang set, which
+// belongs to the given tileset.
+func NewWangFiller(ts *Tileset, ws *WangSet, options ...WangFillerOption) (*WangFiller, error) {
+	f := &WangFiller{tileset: ts, wangSet: ws, exact: make(map[WangID][]*wangCandidate)}
+	for _, o := range options {
+		o(f)
+	}
+
+	for p := range f.mask {
+		corner := p%2 == 1
+		switch ws.Type {
+		case "corner":
+			f.mask[p] = corner
+		case "edge":
+			f.mask[p] = !corner
+		default:
+			f.mask[p] = true
+		}
+	}
+
+	for _, wt := range ws.WangTiles {
+		id, err := ws.tileColors(wt)
+		if err != nil {
+			return nil, err
+		}
+
+		probability := 1.0
+		if t, err := ts.GetTilesetTile(wt.TileID); err == nil {
+			probability = float64(t.Probability)
+		}
+		for p, c := range id {
+			if f.mask[p] && c > 0 {
+				probability *= float64(ws.WangColors[c-1].Probability)
+			}
+		}
+
+		for _, v := range wangVariants(id, ts.Transformations) {
+			c := &wangCandidate{
+				tile: &LayerTile{
+					ID:             wt.TileID,
+					Tileset:        ts,
+					DiagonalFlip:   v.diagonal,
+					HorizontalFlip: v.horizontal,
+					VerticalFlip:   v.vertical,
+				},
+				id:          v.id,
+				probability: probability,
+				transformed: v.diagonal || v.horizontal || v.vertical,
+			}
+			f.candidates = append(f.candidates, c)
+			key := c.id.masked(f.mask)
+			f.exact[key] = append(f.exact[key], c)
+		}
+	}
+
+	if len(f.candidates) == 0 {
+		return nil, ErrNoWangTiles
+	}
+	return f, nil
+}
+
+// Tile returns the best matching tile for the wang colors of the cell at
+// (x, y) of the grid, or nil if the cell has no colors set.
+//
+// The best matching tiles are the ones with the fewest corners and edges of a
+// different color than wanted, ignoring positions without a color. One of them
+// is chosen randomly, weighted by the probabilities of the tile and of its
+// colors. Transformed variants are only considered if no untransformed tile
+// matches equally well when the tileset prefers untransformed tiles.
+func (f *WangFiller) Tile(grid *WangColorGrid, x, y int) *LayerTile {
+	want := grid.Cell(x, y)
+	empty, complete := true, true
+	for p, c := range want {
+		if f.mask[p] {
+			empty = empty && c == 0
+			complete = complete && c != 0
+		}
+	}
+	if empty {
+		return nil
+	}
+
+	preferUntransformed := f.tileset.Transformations != nil && f.tileset.Transformations.PreferUntransformed
+
+	// Fully specified colors usually have exact matches, which are found
+	// without scoring every candidate.
+	var matches []*wangCandidate
+	if complete {
+		matches = f.exact[want.masked(f.mask)]
+	}
+	if len(matches) == 0 {
+		best := -1
+		for _, c := range f.candidates {
+			score := 0
+			for p, color := range want {
+				if f.mask[p] && color != 0 && c.id[p] != color {
+					score++
+				}
+			}
+			if best >= 0 && score > best {
+				continue
+			}
+			if best < 0 || score < best {
+				best = score
+				matches = nil
+			}
+			matches = append(matches, c)
+		}
+	}
+
+	if preferUntransformed {
+		untransformed := matches[:0:0]
+		for _, c := range matches {
+			if !c.transformed {
+				untransformed = append(untransformed, c)
+			}
+		}
+		if len(untransformed) > 0 {
+			matches = untransformed
+		}
+	}
+
+	total := 0.0
+	for _, c := range matches {
+		total += c.probability
+	}
+	pick := matches[len(matches)-1]
+	if total > 0 {
+		r := f.random() * total
+		for _, c := range matches {
+			if r < c.probability {
+				pick = c
+				break
+			}
+			r -= c.probability
+		}
+	}
+
+	t := *pick.tile
+	return &t
+}
+
+func (f *WangFiller) random() float64 {
+	if f.rand != nil {
+		return f.rand.Float64()
+	}
+	return rand.Float64()
+}
+
+// Fill places the best matching tiles for the cells of the grid into the layer,
+// with the top-left cell of the grid at tile (x, y) of the layer. Cells
+// without any colors set, and cells outside of the layer, are left unchanged.
+// The layer must have been decoded as part of a map.
+func (f *WangFiller) Fill(l *Layer, grid *WangColorGrid, x, y int) error {
+	if l._map == nil || len(l.Tiles) != l._map.Width*l._map.Height {
+		return ErrLayerNotDecoded
+	}
+
+	for gy := 0; gy < grid.height; gy++ {
+		for gx := 0; gx < grid.width; gx++ {
+			lx, ly := x+gx, y+gy
+			if lx < 0 || ly < 0 || lx >= l._map.Width || ly >= l._map.Height {
+				continue
+			}
+			if t := f.Tile(grid, gx, gy); t != nil {
+				l.Tiles[ly*l._map.Width+lx] = t
+				l.empty = false
+			}
+		}
+	}
+	return nil
+}
+
+// masked returns the colors at the positions set in mask, with the others
+// unset.
+func (id WangID) masked(mask [8]bool) WangID {
+	for p := range id {
+		if !mask[p] {
+			id[p] = 0
+		}
+	}
+	return id
+}
+
+// wangVariant is a wang ID transformed by a combination of tile flips.
+type wangVariant struct {
+	id                             WangID
+	diagonal, horizontal, vertical bool
+}
+
+// rotate returns the variant rotated by 90 degrees clockwise, which is a
+// diagonal flip followed by a horizontal one.
+func (v wangVariant) rotate() wangVariant {
+	r := wangVariant{diagonal: !v.diagonal, horizontal: !v.vertical, vertical: v.horizontal}
+	for p := range r.id {
+		r.id[p] = v.id[(p+6)%8]
+	}
+	return r
+}
+
+func (v wangVariant) flipHorizontally() wangVariant {
+	r := wangVariant{diagonal: v.diagonal, horizontal: !v.horizontal, vertical: v.vertical}
+	for p := range r.id {
+		r.id[p] = v.id[(8-p)%8]
+	}
+	return r
+}
+
+func (v wangVariant) flipVertically() wangVariant {
+	r := wangVariant{diagonal: v.diagonal, horizontal: v.horizontal, vertical: !v.vertical}
+	for p := range r.id {
+		r.id[p] = v.id[(12-p)%8]
+	}
+	return r
+}
+
+// wangVariants returns the wang ID and all distinct variants of it the
+// transformations allow, starting with the untransformed one.
+func wangVariants(id WangID, t *TilesetTransformations) []wangVariant {
+	variants := []wangVariant{{id: id}}
+	if t == nil {
+		return variants
+	}
+
+	seen := map[[3]bool]bool{{}: true}
+	add := func(v wangVariant) {
+		key := [3]bool{v.diagonal, v.horizontal, v.vertical}
+		if !seen[key] {
+			seen[key] = true
+			variants = append(variants, v)
+		}
+	}
+
+	if t.Rotate {
+		v := variants[0]
+		for range 3 {
+			v = v.rotate()
+			add(v)
+		}
+	}
+	if t.HFlip {
+		for _, v := range variants {
+			add(v.flipHorizontally())
+		}
+	}
+	if t.VFlip {
+		for _, v := range variants {
+			add(v.flipVertically())
+		}
+	}
+	return variants
+}
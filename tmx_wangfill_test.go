@@ -0,0 +1,161 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import (
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWangColorGrid_SharedCorners(t *testing.T) {
+	g := NewWangColorGrid(2, 2)
+	g.Set(0, 0, BottomRight, 3)
+	g.Set(0, 0, Right, 2)
+
+	assert.Equal(t, uint8(3), g.Get(1, 0, BottomLeft))
+	assert.Equal(t, uint8(3), g.Get(0, 1, TopRight))
+	assert.Equal(t, uint8(3), g.Get(1, 1, TopLeft))
+	assert.Equal(t, uint8(2), g.Get(1, 0, Left))
+	assert.Equal(t, uint8(0), g.Get(2, 0, Left))
+}
+
+func TestWangFiller_Fill(t *testing.T) {
+	m, err := LoadFile(filepath.Join(GetAssetsDirectory(), "test_wangsets_map.tmx"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ts := m.Tilesets[0]
+	f, err := NewWangFiller(ts, ts.WangSets[0], WithWangRand(rand.New(rand.NewSource(1))))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Water (3) at the corner shared by the four top-left cells, rock (1)
+	// everywhere else.
+	g := NewWangColorGrid(3, 3)
+	for y := range 3 {
+		for x := range 3 {
+			g.SetCell(x, y, 1)
+		}
+	}
+	g.Set(0, 0, BottomRight, 3)
+
+	l := m.Layers[0]
+	assert.NoError(t, f.Fill(l, g, 10, 20))
+
+	tileAt := func(x, y int) uint32 {
+		return l.Tiles[(20+y)*m.Width+10+x].ID
+	}
+	assert.Equal(t, uint32(15), tileAt(0, 0))
+	assert.Equal(t, uint32(17), tileAt(1, 0))
+	assert.Equal(t, uint32(55), tileAt(0, 1))
+	assert.Equal(t, uint32(57), tileAt(1, 1))
+	assert.Equal(t, uint32(43), tileAt(2, 2))
+	assert.Same(t, ts, l.Tiles[20*m.Width+10].Tileset)
+
+	// Cells without colors are left alone.
+	before := *l.Tiles[0]
+	assert.NoError(t, f.Fill(l, NewWangColorGrid(1, 1), 0, 0))
+	assert.Equal(t, before, *l.Tiles[0])
+}
+
+func TestWangFiller_Transformations(t *testing.T) {
+	ts := &Tileset{
+		Transformations: &TilesetTransformations{Rotate: true},
+		Tiles: []*TilesetTile{
+			{ID: 1, Probability: 1},
+			{ID: 2, Probability: 0},
+		},
+	}
+	ws := &WangSet{
+		Type:       "corner",
+		WangColors: []*WangColor{{Probability: 1}, {Probability: 1}},
+		WangTiles: []*WangTile{
+			{TileID: 0, WangID: "0,1,0,1,0,1,0,1"},
+			// Grass only at the top right corner.
+			{TileID: 1, WangID: "0,2,0,1,0,1,0,1"},
+			// The same tile, but never chosen.
+			{TileID: 2, WangID: "0,2,0,1,0,1,0,1"},
+		},
+	}
+
+	f, err := NewWangFiller(ts, ws, WithWangRand(rand.New(rand.NewSource(1))))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		corner     WangPosition
+		d, h, v    bool
+		transforms string
+	}{
+		{TopRight, false, false, false, "none"},
+		{BottomRight, true, true, false, "rotated 90 degrees"},
+		{BottomLeft, false, true, true, "rotated 180 degrees"},
+		{TopLeft, true, false, true, "rotated 270 degrees"},
+	}
+	for _, tt := range tests {
+		g := NewWangColorGrid(1, 1)
+		g.SetCell(0, 0, 1)
+		g.Set(0, 0, tt.corner, 2)
+
+		for range 10 {
+			tile := f.Tile(g, 0, 0)
+			if assert.NotNil(t, tile) {
+				assert.Equal(t, uint32(1), tile.ID, tt.transforms)
+				assert.Equal(t, tt.d, tile.DiagonalFlip, tt.transforms)
+				assert.Equal(t, tt.h, tile.HorizontalFlip, tt.transforms)
+				assert.Equal(t, tt.v, tile.VerticalFlip, tt.transforms)
+			}
+		}
+	}
+
+	// A flip alone yields a different variant for the same corner.
+	ts.Transformations = &TilesetTransformations{HFlip: true}
+	f, err = NewWangFiller(ts, ws)
+	if !assert.NoError(t, err) {
+		return
+	}
+	g := NewWangColorGrid(1, 1)
+	g.SetCell(0, 0, 1)
+	g.Set(0, 0, TopLeft, 2)
+	assert.Equal(t, LayerTile{ID: 1, Tileset: ts, HorizontalFlip: true}, *f.Tile(g, 0, 0))
+
+	// When untransformed tiles are preferred, an all-rock tile is never
+	// replaced by one of its transformed variants.
+	ts.Transformations.PreferUntransformed = true
+	f, err = NewWangFiller(ts, ws)
+	if !assert.NoError(t, err) {
+		return
+	}
+	g = NewWangColorGrid(1, 1)
+	g.SetCell(0, 0, 1)
+	for range 10 {
+		tile := f.Tile(g, 0, 0)
+		assert.Equal(t, LayerTile{ID: 0, Tileset: ts}, *tile)
+	}
+}
@@ -7,6 +7,13 @@ import (
 	"strings"
 )
 
+var (
+	// ErrInvalidWangID error is returned when a wang tile has a malformed wang ID
+	ErrInvalidWangID = errors.New("tiled: invalid wang ID")
+	// ErrInvalidWangColor error is returned when a wang ID refers to a wang color that doesn't exist
+	ErrInvalidWangColor = errors.New("tiled: wang color index out of range")
+)
+
 // WangSets contains the list of Wang sets defined for this tileset.
 // https://doc.mapeditor.org/en/stable/reference/tmx-map-format/#wangsets
 // Can contain any number: <wangset>
@@ -28,6 +35,11 @@ type WangSet struct {
 	WangColors []*WangColor `xml:"wangcolor"`
 	// The list of wang tiles.
 	WangTiles []*WangTile `xml:"wangtile"`
+
+	// byTile indexes WangTiles by tile ID.
+	byTile map[uint32]*WangTile
+	// byWangID indexes WangTiles by their colors.
+	byWangID map[WangID][]*WangTile
 }
 
 type aliasWangSet WangSet
@@ -50,9 +62,90 @@ func (w *WangSet) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		}
 	}
 
+	return w.BuildIndex()
+}
+
+// BuildIndex parses the WangID of every wang tile into its Colors, checks that
+// they refer to existing wang colors, and indexes the wang tiles by tile ID and
+// by colors for GetWangTile and GetWangTilesByWangID. Tiles without a WangID
+// keep the Colors they have.
+//
+// Wang sets are indexed when they're loaded. BuildIndex must be called again
+// after editing WangTiles, or after building a wang set in code; until then,
+// lookups use the stale index, or scan the wang tiles if there's none. Neither
+// BuildIndex nor edits may happen concurrently with lookups.
+func (w *WangSet) BuildIndex() error {
+	colors := make([]WangID, len(w.WangTiles))
+	for i, t := range w.WangTiles {
+		id, err := w.tileColors(t)
+		if err != nil {
+			return err
+		}
+		colors[i] = id
+	}
+
+	w.byTile = make(map[uint32]*WangTile, len(w.WangTiles))
+	w.byWangID = make(map[WangID][]*WangTile)
+	for i, t := range w.WangTiles {
+		t.Colors = colors[i]
+		if _, ok := w.byTile[t.TileID]; !ok {
+			w.byTile[t.TileID] = t
+		}
+		w.byWangID[t.Colors] = append(w.byWangID[t.Colors], t)
+	}
 	return nil
 }
 
+// tileColors returns the colors of t, parsed from its WangID if it has one,
+// checking that they refer to existing wang colors.
+func (w *WangSet) tileColors(t *WangTile) (WangID, error) {
+	id := t.Colors
+	if t.WangID != "" {
+		var err error
+		if id, err = ParseWangID(t.WangID); err != nil {
+			return id, err
+		}
+	}
+	for _, c := range id {
+		if int(c) > len(w.WangColors) {
+			return id, ErrInvalidWangColor
+		}
+	}
+	return id, nil
+}
+
+// GetWangTile returns the wang tile of the tile with the given tile ID.
+func (w *WangSet) GetWangTile(tileID uint32) (*WangTile, error) {
+	if w.byTile != nil {
+		if t, ok := w.byTile[tileID]; ok {
+			return t, nil
+		}
+	} else {
+		for _, t := range w.WangTiles {
+			if t.TileID == tileID {
+				return t, nil
+			}
+		}
+	}
+	return nil, errors.New("no wangtile matches the given Id")
+}
+
+// GetWangTilesByWangID returns the wang tiles with exactly the given colors,
+// in the order they're defined in. Without an index (see BuildIndex), tiles
+// with an invalid WangID are skipped.
+func (w *WangSet) GetWangTilesByWangID(id WangID) []*WangTile {
+	if w.byWangID != nil {
+		return w.byWangID[id]
+	}
+	var tiles []*WangTile
+	for _, t := range w.WangTiles {
+		if colors, err := w.tileColors(t); err == nil && colors == id {
+			tiles = append(tiles, t)
+		}
+	}
+	return tiles
+}
+
 // WangColor that can be used to define the corner and/or edge of a Wang tile.
 type WangColor struct {
 	// The name of this color.
@@ -77,6 +170,56 @@ type WangTile struct {
 	// Before Tiled 1.5, the Wang ID was saved as a 32-bit unsigned integer stored in the format
 	// 0xCECECECE (where each C is a corner color and each E is an edge color, in reverse order).
 	WangID string `xml:"wangid,attr"`
+	// Colors is the parsed WangID, in either format.
+	Colors WangID `xml:"-"`
+}
+
+// WangID holds the wang color indexes of the eight corners and edges of a
+// tile, in WangPosition order. Indexes start from 1, with 0 meaning unset.
+type WangID [8]uint8
+
+// ParseWangID parses a wang ID, given either as a comma-separated list of
+// eight indexes or in the 32-bit 0xCECECECE format used before Tiled 1.5.
+func ParseWangID(s string) (WangID, error) {
+	var id WangID
+
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		v, err := strconv.ParseUint(s[2:], 16, 32)
+		if err != nil {
+			return id, ErrInvalidWangID
+		}
+		for i := range id {
+			id[i] = uint8(v >> (4 * i) & 0xF)
+		}
+		return id, nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) != len(id) {
+		return id, ErrInvalidWangID
+	}
+	for i, v := range parts {
+		c, err := strconv.ParseUint(strings.TrimSpace(v), 10, 8)
+		if err != nil {
+			return id, ErrInvalidWangID
+		}
+		id[i] = uint8(c)
+	}
+	return id, nil
+}
+
+// Get returns the color index at the given position.
+func (id WangID) Get(p WangPosition) uint8 {
+	return id[p]
+}
+
+// String returns the wang ID in the comma-separated format.
+func (id WangID) String() string {
+	parts := make([]string, len(id))
+	for i, c := range id {
+		parts[i] = strconv.Itoa(int(c))
+	}
+	return strings.Join(parts, ",")
 }
 
 // WangPosition Wang Color mapping to position
@@ -111,40 +254,25 @@ func (w *WangSet) GetWangColors(tileID uint32) (map[WangPosition]*WangColor, err
 		return nil, errors.New("no wangcolors found on this wangset")
 	}
 
-	var tile *WangTile
-	for _, t := range w.WangTiles {
-		if t.TileID == tileID {
-			tile = t
-			break
-		}
-	}
-	if tile == nil {
-		return nil, errors.New("no wangtile matches the given Id")
+	tile, err := w.GetWangTile(tileID)
+	if err != nil {
+		return nil, err
 	}
-
-	// convert from CSV to array of strings
-	wangIDsString := strings.Split(tile.WangID, ",")
-
-	// convert from array of strings to slice of uint32
-	var wangIDs []uint32 // will contain a slice of the wangIDs
-	for _, v := range wangIDsString {
-		id64, err := strconv.ParseUint(v, 10, 32)
-		if err != nil {
-			return nil, errors.New("internal error")
+	colors := tile.Colors
+	if w.byTile == nil {
+		if colors, err = w.tileColors(tile); err != nil {
+			return nil, err
 		}
-
-		// uint64 to uint32
-		id := uint32(id64)
-
-		wangIDs = append(wangIDs, id)
 	}
 
 	wangColors := make(map[WangPosition]*WangColor)
-
-	for i, id := range wangIDs {
-		if id == 0 { // no color assigned if id is 0, set to nil
+	for i, id := range colors {
+		switch {
+		case id == 0: // no color assigned if id is 0, set to nil
 			wangColors[WangPosition(i)] = nil
-		} else {
+		case int(id) > len(w.WangColors):
+			return nil, ErrInvalidWangColor
+		default:
 			wangColors[WangPosition(i)] = w.WangColors[id-1]
 		}
 	}
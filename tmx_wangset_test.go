@@ -0,0 +1,137 @@
+/*
+Copyright (c) 2026 Lauris Bukšis <lauris@nix.lv>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package tiled
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWangID(t *testing.T) {
+	id, err := ParseWangID("0,1,0,3,0,1,0,2")
+	assert.NoError(t, err)
+	assert.Equal(t, WangID{0, 1, 0, 3, 0, 1, 0, 2}, id)
+	assert.Equal(t, uint8(3), id.Get(BottomRight))
+	assert.Equal(t, "0,1,0,3,0,1,0,2", id.String())
+
+	// The legacy format stores the top edge in the lowest nibble.
+	id, err = ParseWangID("0x20103010")
+	assert.NoError(t, err)
+	assert.Equal(t, WangID{0, 1, 0, 3, 0, 1, 0, 2}, id)
+
+	for _, s := range []string{"", "1,2,3", "0,1,0,1,0,1,0,x", "0,1,0,1,0,1,0,256", "0xZZ"} {
+		_, err := ParseWangID(s)
+		assert.ErrorIs(t, err, ErrInvalidWangID, s)
+	}
+}
+
+func TestWangSet_Index(t *testing.T) {
+	m, err := LoadFile(filepath.Join(GetAssetsDirectory(), "test_wangsets_map.tmx"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ws := m.Tilesets[0].WangSets[0]
+	tile, err := ws.GetWangTile(15)
+	if assert.NoError(t, err) {
+		assert.Equal(t, WangID{0, 1, 0, 3, 0, 1, 0, 1}, tile.Colors)
+	}
+	_, err = ws.GetWangTile(0)
+	assert.Error(t, err)
+
+	// Tiles 36, 41 and 151 are all water.
+	tiles := ws.GetWangTilesByWangID(WangID{0, 3, 0, 3, 0, 3, 0, 3})
+	if assert.Len(t, tiles, 3) {
+		assert.Equal(t, uint32(36), tiles[0].TileID)
+		assert.Equal(t, uint32(41), tiles[1].TileID)
+		assert.Equal(t, uint32(151), tiles[2].TileID)
+	}
+}
+
+func TestWangSet_InvalidColor(t *testing.T) {
+	r := strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" name="wang" tilewidth="16" tileheight="16" tilecount="1" columns="1">
+ <wangsets>
+  <wangset name="broken" type="corner" tile="-1">
+   <wangcolor name="Grass" color="#00ff00" tile="-1" probability="1"/>
+   <wangtile tileid="0" wangid="0,1,0,2,0,1,0,1"/>
+  </wangset>
+ </wangsets>
+</tileset>`)
+	_, err := LoadTilesetReader(GetAssetsDirectory(), r)
+	assert.ErrorIs(t, err, ErrInvalidWangColor)
+
+	// Wang sets built in code are checked when they're used.
+	ws := &WangSet{
+		WangColors: []*WangColor{{Name: "Grass"}},
+		WangTiles:  []*WangTile{{TileID: 0, WangID: "0,1,0,2,0,1,0,1"}},
+	}
+	_, err = ws.GetWangColors(0)
+	assert.ErrorIs(t, err, ErrInvalidWangColor)
+}
+
+func TestWangSet_BuildIndex(t *testing.T) {
+	ws := &WangSet{
+		WangColors: []*WangColor{{Name: "Grass"}, {Name: "Water"}},
+		WangTiles:  []*WangTile{{TileID: 0, WangID: "0,1,0,1,0,1,0,1"}, {TileID: 1, WangID: "1,2,3"}},
+	}
+	assert.ErrorIs(t, ws.BuildIndex(), ErrInvalidWangID)
+
+	// Without an index, lookups scan the wang tiles without changing them,
+	// so they're safe to run concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tile, err := ws.GetWangTile(0)
+			assert.NoError(t, err)
+			assert.Equal(t, []*WangTile{tile}, ws.GetWangTilesByWangID(WangID{0, 1, 0, 1, 0, 1, 0, 1}))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, WangID{}, ws.WangTiles[0].Colors)
+
+	ws.WangTiles[1].WangID = "0,2,0,2,0,2,0,2"
+	if !assert.NoError(t, ws.BuildIndex()) {
+		return
+	}
+	assert.Equal(t, WangID{0, 2, 0, 2, 0, 2, 0, 2}, ws.WangTiles[1].Colors)
+	assert.Equal(t, []*WangTile{ws.WangTiles[1]}, ws.GetWangTilesByWangID(WangID{0, 2, 0, 2, 0, 2, 0, 2}))
+
+	// Edits show up once the index is rebuilt.
+	ws.WangTiles = append(ws.WangTiles, &WangTile{TileID: 2, Colors: WangID{0, 2, 0, 2, 0, 2, 0, 2}})
+	assert.Len(t, ws.GetWangTilesByWangID(WangID{0, 2, 0, 2, 0, 2, 0, 2}), 1)
+	if !assert.NoError(t, ws.BuildIndex()) {
+		return
+	}
+	assert.Len(t, ws.GetWangTilesByWangID(WangID{0, 2, 0, 2, 0, 2, 0, 2}), 2)
+	tile, err := ws.GetWangTile(2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, ws.WangTiles[2], tile)
+	}
+}